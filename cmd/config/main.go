@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	_ "github.com/aiechoic/admin/core/crypto"
+	_ "github.com/aiechoic/admin/core/email"
+	_ "github.com/aiechoic/admin/core/env"
+	_ "github.com/aiechoic/admin/core/gin"
+	_ "github.com/aiechoic/admin/core/gorm"
+	_ "github.com/aiechoic/admin/core/health"
+	"github.com/aiechoic/admin/core/ioc"
+	_ "github.com/aiechoic/admin/core/jwt"
+	_ "github.com/aiechoic/admin/core/metrics"
+	_ "github.com/aiechoic/admin/core/openapi"
+	_ "github.com/aiechoic/admin/core/ratelimit"
+	_ "github.com/aiechoic/admin/core/redis"
+	_ "github.com/aiechoic/admin/core/verify"
+	"github.com/aiechoic/admin/core/viper"
+	spf13 "github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	viper.BindFlags(flag.CommandLine)
+	// Customize the usage function
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", "config")
+		fmt.Fprintln(os.Stderr, "This program manages the configs of an environment through the selected config adapter.")
+		fmt.Fprintln(os.Stderr, "\nCommands:")
+		fmt.Fprintln(os.Stderr, "  init [names...]   write the default config files which do not exist yet")
+		fmt.Fprintln(os.Stderr, "  print [names...]  print the config loaded by the application, environment variables and secret")
+		fmt.Fprintln(os.Stderr, "                    references resolved, secrets redacted, without writing the missing files")
+		fmt.Fprintln(os.Stderr, "  diff [names...]   list the keys added to or removed from the defaults since the files were written")
+		fmt.Fprintln(os.Stderr, "\ninit and diff work on the files holding the defaults, they are not supported by the \"fs\" adapter.")
+		fmt.Fprintln(os.Stderr, "  env [names...]    list the environment variables overriding the configs")
+		fmt.Fprintln(os.Stderr, "\nOptions:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExamples:")
+		fmt.Fprintln(os.Stderr, "config -env production -config-dir /etc/app init")
+		fmt.Fprintln(os.Stderr, "config print gin-server gorm")
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c := ioc.NewContainer()
+	defaults, err := selectDefaults(flag.Args()[1:])
+	if err == nil {
+		switch flag.Arg(0) {
+		case "init":
+			err = initConfigs(c, defaults)
+		case "print":
+			err = printConfigs(c, defaults)
+		case "diff":
+			err = diffConfigs(c, defaults)
+		case "env":
+			err = listEnvVars(defaults)
+		default:
+			flag.Usage()
+			os.Exit(2)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func selectDefaults(names []string) ([]viper.Default, error) {
+	if len(names) == 0 {
+		return viper.Defaults(), nil
+	}
+	var ds []viper.Default
+	for _, name := range names {
+		d, ok := viper.LookupDefault(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown config %q", name)
+		}
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
+// fileAdapter returns the adapter of c if it stores the configs in files.
+func fileAdapter(c *ioc.Container) (viper.FileAdapter, error) {
+	adapter, err := viper.GetAdapter(c)
+	if err != nil {
+		return nil, err
+	}
+	files, ok := adapter.(viper.FileAdapter)
+	if !ok {
+		return nil, fmt.Errorf("config adapter %q does not store the configs in files", viper.ActiveAdapter())
+	}
+	return files, nil
+}
+
+func initConfigs(c *ioc.Container, defaults []viper.Default) error {
+	files, err := fileAdapter(c)
+	if err != nil {
+		return err
+	}
+	for _, d := range defaults {
+		file := files.DefaultFile(d.Name, d.Ext())
+		if _, err := os.Stat(file); err == nil {
+			fmt.Printf("exists  %s\n", file)
+			continue
+		}
+		err := os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(file, []byte(d.Config()), 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", file)
+	}
+	return nil
+}
+
+func printConfigs(c *ioc.Container, defaults []viper.Default) error {
+	for _, d := range defaults {
+		v, err := viper.ReadViper(d.Name, d.Config(), c)
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(viper.RedactedSettings(v))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# %s\n%s\n", d.Name, data)
+	}
+	return nil
+}
+
+var errDiff = errors.New("the config files differ from the defaults")
+
+func diffConfigs(c *ioc.Container, defaults []viper.Default) error {
+	files, err := fileAdapter(c)
+	if err != nil {
+		return err
+	}
+	var differ bool
+	for _, d := range defaults {
+		file := files.DefaultFile(d.Name, d.Ext())
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			fmt.Printf("%s: not found, run init\n", file)
+			differ = true
+			continue
+		}
+		v := spf13.New()
+		v.SetConfigFile(file)
+		err := v.ReadInConfig()
+		if err != nil {
+			return fmt.Errorf("reading config file \"%s\": %w", file, err)
+		}
+		missing, unknown, err := viper.DiffKeys(d.Config(), v)
+		if err != nil {
+			return fmt.Errorf("config '%s': %w", d.Name, err)
+		}
+		if len(missing) == 0 && len(unknown) == 0 {
+			continue
+		}
+		differ = true
+		fmt.Printf("%s:\n", file)
+		for _, key := range missing {
+			fmt.Printf("  + %s (missing, added to the defaults)\n", key)
+		}
+		for _, key := range unknown {
+			fmt.Printf("  - %s (unknown, no longer used)\n", key)
+		}
+	}
+	if differ {
+		return errDiff
+	}
+	return nil
+}
+
+func listEnvVars(defaults []viper.Default) error {
+	for _, d := range defaults {
+		vars, err := viper.EnvVars(d.Name, d.Config())
+		if err != nil {
+			return fmt.Errorf("config '%s': %w", d.Name, err)
+		}
+		fmt.Printf("# %s\n", d.Name)
+		for _, name := range vars {
+			fmt.Println(name)
+		}
+		fmt.Println()
+	}
+	return nil
+}
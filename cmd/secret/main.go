@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/aiechoic/admin/core/crypto"
+	"github.com/aiechoic/admin/core/random"
+	"github.com/aiechoic/admin/core/viper"
+	"os"
+	"strings"
+)
+
+func main() {
+	decrypt := flag.Bool("d", false, "decrypt the value instead of encrypting it")
+	genKey := flag.Bool("k", false, "generate a random master key")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", "secret")
+		fmt.Fprintf(os.Stderr, "This program encrypts a config value with the master key from $%s, the value is read\n", crypto.MasterKeyEnvVar)
+		fmt.Fprintln(os.Stderr, "from the arguments or the standard input.")
+		fmt.Fprintln(os.Stderr, "Options:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExamples:")
+		fmt.Fprintln(os.Stderr, "secret -k")
+		fmt.Fprintln(os.Stderr, "secret my_password")
+		fmt.Fprintln(os.Stderr, "secret -d enc:bm9uY2UuLi4=")
+	}
+	flag.Parse()
+	if *genKey {
+		fmt.Println(random.String(32))
+		return
+	}
+	value := strings.Join(flag.Args(), " ")
+	if value == "" {
+		scanner := bufio.NewScanner(os.Stdin)
+		if scanner.Scan() {
+			value = scanner.Text()
+		}
+	}
+	if value == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var err error
+	if *decrypt {
+		var a *crypto.AESCipher
+		a, err = crypto.MasterKeyCipher()
+		if err == nil {
+			var plain []byte
+			plain, err = a.DecryptText([]byte(strings.TrimPrefix(value, viper.EncryptedPrefix)))
+			value = string(plain)
+		}
+	} else {
+		value, err = crypto.EncryptSecret(value)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(value)
+}
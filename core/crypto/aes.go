@@ -19,12 +19,16 @@ var initAESConfig = `
 # AES-GCM 加密算法配置
 
 # AES-GCM 加密算法的密钥, 长度必须是 16, 24, 32 字节
-# 生产环境中请使用环境变量来设置密钥, 如: export AES-GCM_KEY=your_key
+# 生产环境中请使用环境变量来设置密钥, 如: export AES_GCM_KEY=your_key
 key: "` + random.String(32) + `"
 `
 
 type aesConfig struct {
-	Key string `mapstructure:"key"`
+	Key string `mapstructure:"key" validate:"required"`
+}
+
+func init() {
+	viper.RegisterDefault(DefaultAESConfig, initAESConfig)
 }
 
 var AESProviders = ioc.NewProviders[*AESCipher](func(name string, args ...any) *ioc.Provider[*AESCipher] {
@@ -34,7 +38,7 @@ var AESProviders = ioc.NewProviders[*AESCipher](func(name string, args ...any) *
 			return nil, err
 		}
 		var cfg aesConfig
-		if err := vp.Unmarshal(&cfg); err != nil {
+		if err := viper.Unmarshal(name, vp, &cfg); err != nil {
 			return nil, err
 		}
 		return NewAESCipher([]byte(cfg.Key))
@@ -0,0 +1,51 @@
+package crypto
+
+import (
+	"fmt"
+	"github.com/aiechoic/admin/core/viper"
+	"os"
+)
+
+// MasterKeyEnvVar 是加密配置值的主密钥所在的环境变量, 长度必须是 16, 24, 32 字节
+const MasterKeyEnvVar = "APP_MASTER_KEY"
+
+func init() {
+	viper.SetDecrypter(masterKeyDecrypter{})
+}
+
+// MasterKeyCipher 返回使用主密钥的 AES-GCM 加密算法实例
+func MasterKeyCipher() (*AESCipher, error) {
+	key, ok := os.LookupEnv(MasterKeyEnvVar)
+	if !ok || key == "" {
+		return nil, fmt.Errorf("master key is not set, export %s=your_key", MasterKeyEnvVar)
+	}
+	return NewAESCipher([]byte(key))
+}
+
+// EncryptSecret 使用主密钥加密配置值, 返回的值可以直接写入配置文件, 如: enc:bm9uY2UuLi4=
+func EncryptSecret(plainText string) (string, error) {
+	a, err := MasterKeyCipher()
+	if err != nil {
+		return "", err
+	}
+	cipherText, err := a.EncryptText([]byte(plainText))
+	if err != nil {
+		return "", err
+	}
+	return viper.EncryptedPrefix + string(cipherText), nil
+}
+
+// masterKeyDecrypter 使用主密钥解密配置值
+type masterKeyDecrypter struct{}
+
+func (masterKeyDecrypter) Decrypt(value string) (string, error) {
+	a, err := MasterKeyCipher()
+	if err != nil {
+		return "", err
+	}
+	plainText, err := a.DecryptText([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return string(plainText), nil
+}
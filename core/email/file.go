@@ -0,0 +1,44 @@
+package email
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSender writes the emails to files in the configured directory instead of sending them, it is meant
+// for development and testing environments.
+type FileSender struct {
+	opts *SenderConfig
+	tpl  *template.Template
+}
+
+func (s *FileSender) Send(to string, data map[string]interface{}) error {
+	m, err := render(s.opts, s.tpl, to, data)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(s.opts.FileDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create email directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), sanitizeFilename(to))
+	filename := filepath.Join(s.opts.FileDir, name)
+	err = os.WriteFile(filename, m, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
+
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -2,12 +2,10 @@ package email
 
 import (
 	"bytes"
-	"crypto/tls"
 	"fmt"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/viper"
 	"html/template"
-	"net/smtp"
 )
 
 const DefaultSenderConfig = "email-sender"
@@ -15,6 +13,12 @@ const DefaultSenderConfig = "email-sender"
 var senderInitConfig = `
 # email sender config
 
+# sender driver, "smtp" sends the emails, "file" writes them to "file_dir" for development
+driver: "smtp"
+
+# directory for the "file" driver
+file_dir: "emails"
+
 # title of the email
 title: "Your Company"
 
@@ -27,7 +31,7 @@ host: "smtp.example.com"
 # smtp port
 port: "465"
 
-# smtp password
+# smtp password, can be referenced as "${env:NAME}" or "${file:/run/secrets/NAME}", or encrypted as "enc:..."
 password: "password"
 
 # email template file
@@ -37,121 +41,99 @@ template: "templates/email/verify.gohtml"
 subject: "Email Verification"
 `
 
-var SenderProviders = ioc.NewProviders[*Sender](func(name string, args ...any) *ioc.Provider[*Sender] {
-	return ioc.NewProvider(func(c *ioc.Container) (*Sender, error) {
+// Sender sends emails rendered from the configured template.
+type Sender interface {
+	Send(to string, data map[string]interface{}) error
+}
+
+func init() {
+	viper.RegisterDefault(DefaultSenderConfig, senderInitConfig)
+	ioc.RegisterConfigDriver("smtp", func(c *ioc.Container, cfg *SenderConfig) (Sender, error) {
+		return &SMTPSender{name: cfg.name, opts: cfg, tpl: cfg.tpl}, nil
+	})
+	ioc.RegisterConfigDriver("file", func(c *ioc.Container, cfg *SenderConfig) (Sender, error) {
+		return &FileSender{opts: cfg, tpl: cfg.tpl}, nil
+	})
+}
 
-		vp, err := viper.GetViper(name, senderInitConfig, c)
+// SenderProviders defines the providers for Sender, the implementation is selected by the "driver" config
+// key among the Sender drivers, more can be registered with ioc.RegisterConfigDriver taking the decoded
+// *SenderConfig.
+var SenderProviders = ioc.NewProviders[Sender](func(name string, args ...any) *ioc.Provider[Sender] {
+	return ioc.NewProvider(func(c *ioc.Container) (Sender, error) {
+		cfg, err := getSenderConfig(name, c)
 		if err != nil {
 			return nil, err
 		}
-
-		var cfg emailSenderConfig
-		err = vp.Unmarshal(&cfg)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal '%s' config: %w", name, err)
+		driver := cfg.Driver
+		if driver == "" {
+			driver = "smtp"
 		}
-
-		var tpl *template.Template
-		if cfg.Template != "" {
-			tpl, err = template.ParseFiles(cfg.Template)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse email template: %w", err)
-			}
+		sender, err := ioc.NewConfigDriver[Sender](driver, c, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config '%s': %w", name, err)
 		}
-
-		return &Sender{
-			opts: &cfg,
-			tpl:  tpl,
-		}, nil
+		return sender, nil
 	})
 })
 
-func GetSender(name string, c *ioc.Container) (*Sender, error) {
+func getSenderConfig(name string, c *ioc.Container) (*SenderConfig, error) {
+	vp, err := viper.GetViper(name, senderInitConfig, c)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := SenderConfig{name: name}
+	err = viper.Unmarshal(name, vp, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Template != "" {
+		cfg.tpl, err = template.ParseFiles(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template: %w", err)
+		}
+	}
+	return &cfg, nil
+}
+
+func GetSender(name string, c *ioc.Container) (Sender, error) {
 	return SenderProviders.GetProvider(name).Get(c)
 }
 
-func GetDefaultSender(c *ioc.Container) (*Sender, error) {
+func GetDefaultSender(c *ioc.Container) (Sender, error) {
 	return GetSender(DefaultSenderConfig, c)
 }
 
-type emailSenderConfig struct {
+// SenderConfig is the sender config, the Sender drivers receive it decoded together with the parsed
+// template.
+type SenderConfig struct {
+	Driver   string `mapstructure:"driver"`
+	FileDir  string `mapstructure:"file_dir" validate:"required_if=Driver file"`
 	Title    string `mapstructure:"title"`
-	From     string `mapstructure:"from"`
-	Host     string `mapstructure:"host"`
-	Port     string `mapstructure:"port"`
+	From     string `mapstructure:"from" validate:"required,email"`
+	Host     string `mapstructure:"host" validate:"required_if=Driver smtp"`
+	Port     string `mapstructure:"port" validate:"required_if=Driver smtp"`
 	Password string `mapstructure:"password"`
 	Template string `mapstructure:"template"`
 	Subject  string `mapstructure:"subject"`
+	name     string
+	tpl      *template.Template
 }
 
-type Sender struct {
-	opts *emailSenderConfig
-	tpl  *template.Template
-}
-
-func (s *Sender) Send(to string, data map[string]interface{}) error {
+// render renders the email message with the MIME headers.
+func render(opts *SenderConfig, tpl *template.Template, to string, data map[string]interface{}) ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
-	err := s.tpl.Execute(buf, data)
+	err := tpl.Execute(buf, data)
 	if err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+		return nil, fmt.Errorf("failed to execute template: %w", err)
 	}
-	m := "From: " + s.opts.Title + " <" + s.opts.From + ">\n" +
+	m := "From: " + opts.Title + " <" + opts.From + ">\n" +
 		"To: " + to + "\n" +
-		"Subject: " + s.opts.Subject + "\n" +
+		"Subject: " + opts.Subject + "\n" +
 		"MIME-Version: 1.0\n" +
 		"Content-Type: text/html; charset=\"UTF-8\"\n\n" +
 		buf.String()
-
-	auth := smtp.PlainAuth("", s.opts.From, s.opts.Password, s.opts.Host)
-
-	// 设置 TLS 配置
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		ServerName:         s.opts.Host,
-	}
-
-	conn, err := tls.Dial("tcp", s.opts.Host+":"+s.opts.Port, tlsConfig)
-	if err != nil {
-		return fmt.Errorf("failed to dial: %w", err)
-	}
-
-	client, err := smtp.NewClient(conn, s.opts.Host)
-	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
-	}
-
-	// 验证身份
-	if err = client.Auth(auth); err != nil {
-		return fmt.Errorf("failed to auth: %w", err)
-	}
-
-	// 设置发件人和收件人
-	if err = client.Mail(s.opts.From); err != nil {
-		return fmt.Errorf("failed to set mail: %w", err)
-	}
-	if err = client.Rcpt(to); err != nil {
-		return fmt.Errorf("failed to set rcpt: %w", err)
-	}
-
-	// 获取写入邮件数据的写入器
-	writer, err := client.Data()
-	if err != nil {
-		return fmt.Errorf("failed to get writer: %w", err)
-	}
-
-	_, err = writer.Write([]byte(m))
-	if err != nil {
-		return fmt.Errorf("failed to write: %w", err)
-	}
-
-	err = writer.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close writer: %w", err)
-	}
-
-	err = client.Quit()
-	if err != nil {
-		return fmt.Errorf("failed to quit: %w", err)
-	}
-	return nil
+	return []byte(m), nil
 }
@@ -0,0 +1,108 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"net/smtp"
+)
+
+// SMTPSender sends emails with a smtp server over TLS.
+type SMTPSender struct {
+	name string
+	opts *SenderConfig
+	tpl  *template.Template
+}
+
+// dial connects to the smtp server over TLS.
+func (s *SMTPSender) dial(ctx context.Context) (*smtp.Client, error) {
+	// 设置 TLS 配置
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         s.opts.Host,
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", s.opts.Host+":"+s.opts.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, s.opts.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return client, nil
+}
+
+// CheckHealth connects to the smtp server and sends a NOOP command, it implements health.Checker.
+func (s *SMTPSender) CheckHealth(ctx context.Context) error {
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	// smtp.Client has no context support, close the connection to abort the commands when ctx is done
+	stop := context.AfterFunc(ctx, func() { _ = client.Close() })
+	defer stop()
+	if err = client.Noop(); err != nil {
+		return fmt.Errorf("failed to noop: %w", err)
+	}
+	return client.Quit()
+}
+
+func (s *SMTPSender) HealthName() string {
+	return "email(" + s.name + ")"
+}
+
+func (s *SMTPSender) Send(to string, data map[string]interface{}) error {
+	m, err := render(s.opts, s.tpl, to, data)
+	if err != nil {
+		return err
+	}
+
+	auth := smtp.PlainAuth("", s.opts.From, s.opts.Password, s.opts.Host)
+
+	client, err := s.dial(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// 验证身份
+	if err = client.Auth(auth); err != nil {
+		return fmt.Errorf("failed to auth: %w", err)
+	}
+
+	// 设置发件人和收件人
+	if err = client.Mail(s.opts.From); err != nil {
+		return fmt.Errorf("failed to set mail: %w", err)
+	}
+	if err = client.Rcpt(to); err != nil {
+		return fmt.Errorf("failed to set rcpt: %w", err)
+	}
+
+	// 获取写入邮件数据的写入器
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to get writer: %w", err)
+	}
+
+	_, err = writer.Write(m)
+	if err != nil {
+		return fmt.Errorf("failed to write: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	err = client.Quit()
+	if err != nil {
+		return fmt.Errorf("failed to quit: %w", err)
+	}
+	return nil
+}
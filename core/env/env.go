@@ -19,13 +19,17 @@ type Env struct {
 	SecretKey string `mapstructure:"secret_key"`
 }
 
+func init() {
+	viper.RegisterDefault(DefaultEnvConfig, initConfig)
+}
+
 var envProvider = ioc.NewProvider(func(c *ioc.Container) (*Env, error) {
 	vp, err := viper.GetViper(DefaultEnvConfig, initConfig, c)
 	if err != nil {
 		return nil, err
 	}
 	var server Env
-	err = vp.Unmarshal(&server)
+	err = viper.Unmarshal(DefaultEnvConfig, vp, &server)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,129 @@
+package gin
+
+import (
+	"github.com/aiechoic/admin/core/jwt"
+	"github.com/aiechoic/admin/pkg/rsp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"math/rand/v2"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader is the header carrying the request id, it is propagated from the request when valid and
+// always set on the response.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLen = 128
+
+// RequestID returns the id of the request, empty if the request id middleware is not installed.
+func RequestID(c *gin.Context) string {
+	return c.GetString(rsp.RequestIDKey)
+}
+
+// requestIDHandler reuses the incoming request id when valid, otherwise generates one.
+func requestIDHandler(c *gin.Context) {
+	id := c.GetHeader(RequestIDHeader)
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+	c.Set(rsp.RequestIDKey, id)
+	c.Header(RequestIDHeader, id)
+	c.Next()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// accessLogger writes one json line per request, the requests are sampled by sampleRate and the paths
+// matching exclude are skipped, the server errors are always logged.
+type accessLogger struct {
+	logger     *logrus.Logger
+	sampleRate float64
+	exclude    []string
+}
+
+func newAccessLogger(c *Config) *accessLogger {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	return &accessLogger{
+		logger:     logger,
+		sampleRate: c.AccessLogSampleRate,
+		exclude:    c.AccessLogExclude,
+	}
+}
+
+func (a *accessLogger) handle(c *gin.Context) {
+	if a.excluded(c.Request.URL.Path) {
+		c.Next()
+		return
+	}
+	start := time.Now()
+	c.Next()
+	status := c.Writer.Status()
+	if status < 500 && !a.sampled() {
+		return
+	}
+	route := c.FullPath()
+	if route == "" {
+		// unmatched routes are not logged by the raw path to keep the cardinality bounded
+		route = "-"
+	}
+	entry := a.logger.WithFields(logrus.Fields{
+		"request_id": RequestID(c),
+		"method":     c.Request.Method,
+		"route":      route,
+		"status":     status,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"bytes":      max(c.Writer.Size(), 0),
+		"client_ip":  c.ClientIP(),
+	})
+	if user := c.GetString(jwt.IdentityKey); user != "" {
+		entry = entry.WithField("user", user)
+	}
+	if len(c.Errors) > 0 {
+		entry = entry.WithField("error", c.Errors.String())
+	}
+	switch {
+	case status >= 500:
+		entry.Error("access")
+	case status >= 400:
+		entry.Warn("access")
+	default:
+		entry.Info("access")
+	}
+}
+
+func (a *accessLogger) sampled() bool {
+	return a.sampleRate >= 1 || rand.Float64() < a.sampleRate
+}
+
+// excluded reports whether p matches one of the exclusion patterns, a pattern ending with "/*" matches
+// the whole subtree, the others are matched by path.Match.
+func (a *accessLogger) excluded(p string) bool {
+	for _, pattern := range a.exclude {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
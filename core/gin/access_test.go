@@ -0,0 +1,92 @@
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aiechoic/admin/core/jwt"
+	"github.com/aiechoic/admin/pkg/errs"
+	"github.com/aiechoic/admin/pkg/rsp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newAccessTestEngine(a *accessLogger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestIDHandler, a.handle)
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Set(jwt.IdentityKey, "42")
+		c.String(http.StatusOK, RequestID(c))
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+	r.GET("/error", func(c *gin.Context) {
+		rsp.SendError(c, errs.BadRequest, nil)
+	})
+	r.GET("/docs/index", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestAccessLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := newAccessLogger(&Config{AccessLogSampleRate: 1, AccessLogExclude: []string{"/docs/*"}})
+	a.logger.SetOutput(buf)
+	r := newAccessTestEngine(a)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "abc-123", w.Body.String())
+
+	var line map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "abc-123", line["request_id"])
+	assert.Equal(t, "/users/:id", line["route"])
+	assert.Equal(t, float64(http.StatusOK), line["status"])
+	assert.Equal(t, float64(len("abc-123")), line["bytes"])
+	assert.Equal(t, "42", line["user"])
+
+	buf.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/docs/index", nil))
+	assert.Empty(t, buf.String())
+}
+
+func TestAccessLog_Sampling(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := newAccessLogger(&Config{AccessLogSampleRate: 0})
+	a.logger.SetOutput(buf)
+	r := newAccessTestEngine(a)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	assert.Empty(t, buf.String())
+
+	// server errors are logged regardless of the sampling
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(RequestIDHeader, strings.Repeat("x", maxRequestIDLen+1))
+	r.ServeHTTP(w, req)
+	id := w.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 36)
+	assert.Contains(t, buf.String(), id)
+}
+
+func TestRequestID_ErrorResponse(t *testing.T) {
+	r := newAccessTestEngine(newAccessLogger(&Config{AccessLogSampleRate: 0}))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/error", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	r.ServeHTTP(w, req)
+
+	var body rsp.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "abc-123", body.RequestID)
+}
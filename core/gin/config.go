@@ -1,8 +1,12 @@
 package gin
 
 import (
+	"fmt"
+	"github.com/aiechoic/admin/core/viper"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,14 +18,60 @@ var initConfig = `
 # http port to listen on
 http_port: 8080
 
+# address to listen on instead of http_port, such as "127.0.0.1:8080" or "unix:/run/app.sock"
+listen: ""
+
+# timeouts of the http server, "0s" means no timeout
+read_timeout: "0s"
+read_header_timeout: "10s"
+write_timeout: "0s"
+idle_timeout: "120s"
+
+# grace period given to the open connections when the server shuts down
+shutdown_timeout: "5s"
+
+# time between failing the readiness checks and closing the listeners when the server shuts down, so the
+# load balancer stops routing new traffic to it first
+shutdown_delay: "0s"
+
 # api root path
 api_root: "/api/v1"
 
-# gin mode, can be "debug", "release", "test" or "" for default 
-gin_mode: "debug"
+# gin mode, can be "debug", "release", "test" or "" for default, "release" in production
+gin_mode: "$gin_mode"
+
+# maximum time for building the required providers before the server starts
+warmup_timeout: "30s"
+
+# serve https when the certificate and key files are set, the certificate is reloaded when the files
+# change or the process receives SIGHUP
+tls_cert_file: ""
+tls_key_file: ""
+
+# minimum tls version, can be "1.0", "1.1", "1.2" or "1.3"
+tls_min_version: "1.2"
+
+# ca file for verifying the client certificates (mTLS), clients without a valid certificate are rejected
+tls_client_ca_file: ""
+
+# enable http/2, only used with tls
+http2: true
 
-# enable default logger middleware
-enable_logger: true
+# http port redirecting to https, 0 to disable, only used with tls
+redirect_http_port: 0
+
+# enable the gin text logger middleware, superseded by the access log
+enable_logger: false
+
+# enable the json access log, one line per request with the request id, the route, the status, the
+# latency, the response size and the authenticated user
+access_log: true
+
+# fraction of the requests logged between 0 and 1, the server errors are always logged
+access_log_sample_rate: 1.0
+
+# paths not logged, "/*" suffix matches the whole subtree, such as "/api/v1/docs/*"
+access_log_exclude: []
 
 # enable default recovery middleware
 enable_recovery: true
@@ -29,7 +79,8 @@ enable_recovery: true
 # enable cross-origin resource sharing
 enable_cors: true
 
-# cors configurations, only used when enable_cors is true
+# cors configurations, only used when enable_cors is true, the cors settings are reloaded when the file
+# changes if the config adapter watches the files
 cors_allow_methods: ["GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"]
 cors_allow_headers: ["Origin", "Content-Length", "Content-Type", "Authorization"]
 cors_allow_credentials: false
@@ -38,40 +89,116 @@ cors_max_age: "12h"
 
 `
 
+// defaultConfig returns the initial configuration adapted to the active environment.
+func defaultConfig() string {
+	ginMode := gin.DebugMode
+	if viper.IsProduction() {
+		ginMode = gin.ReleaseMode
+	}
+	return strings.Replace(initConfig, "$gin_mode", ginMode, -1)
+}
+
 type Config struct {
-	ApiRoot              string        `mapstructure:"api_root"`
-	HttpPort             int           `mapstructure:"http_port"`
-	GinMode              string        `mapstructure:"gin_mode"`
+	ApiRoot              string        `mapstructure:"api_root" validate:"omitempty,startswith=/"`
+	HttpPort             int           `mapstructure:"http_port" validate:"min=1,max=65535"`
+	Listen               string        `mapstructure:"listen"`
+	ReadTimeout          time.Duration `mapstructure:"read_timeout" validate:"min=0"`
+	ReadHeaderTimeout    time.Duration `mapstructure:"read_header_timeout" validate:"min=0"`
+	WriteTimeout         time.Duration `mapstructure:"write_timeout" validate:"min=0"`
+	IdleTimeout          time.Duration `mapstructure:"idle_timeout" validate:"min=0"`
+	ShutdownTimeout      time.Duration `mapstructure:"shutdown_timeout" validate:"min=0"`
+	ShutdownDelay        time.Duration `mapstructure:"shutdown_delay" validate:"min=0"`
+	GinMode              string        `mapstructure:"gin_mode" validate:"omitempty,oneof=debug release test"`
+	WarmupTimeout        time.Duration `mapstructure:"warmup_timeout" validate:"min=0"`
+	TLSCertFile          string        `mapstructure:"tls_cert_file" validate:"required_with=TLSKeyFile"`
+	TLSKeyFile           string        `mapstructure:"tls_key_file" validate:"required_with=TLSCertFile"`
+	TLSMinVersion        string        `mapstructure:"tls_min_version" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	TLSClientCAFile      string        `mapstructure:"tls_client_ca_file"`
+	HTTP2                bool          `mapstructure:"http2"`
+	RedirectHttpPort     int           `mapstructure:"redirect_http_port" validate:"min=0,max=65535"`
 	EnableLogger         bool          `mapstructure:"enable_logger"`
+	AccessLog            bool          `mapstructure:"access_log"`
+	AccessLogSampleRate  float64       `mapstructure:"access_log_sample_rate" validate:"min=0,max=1"`
+	AccessLogExclude     []string      `mapstructure:"access_log_exclude"`
 	EnableRecovery       bool          `mapstructure:"enable_recovery"`
 	EnableCORS           bool          `mapstructure:"enable_cors"`
 	CorsAllowMethods     []string      `mapstructure:"cors_allow_methods"`
 	CorsAllowHeaders     []string      `mapstructure:"cors_allow_headers"`
 	CorsAllowCredentials bool          `mapstructure:"cors_allow_credentials"`
-	CorsAllowOrigins     []string      `mapstructure:"cors_allow_origins"`
-	CorsMaxAge           time.Duration `mapstructure:"cors_max_age"`
+	CorsAllowOrigins     []string      `mapstructure:"cors_allow_origins" validate:"required_if=EnableCORS true"`
+	CorsMaxAge           time.Duration `mapstructure:"cors_max_age" validate:"min=0"`
+}
+
+// corsConfig returns the cors settings, or nil if cors is disabled.
+func (c *Config) corsConfig() (*cors.Config, error) {
+	if !c.EnableCORS {
+		return nil, nil
+	}
+	cfg := &cors.Config{
+		AllowMethods:     c.CorsAllowMethods,
+		AllowHeaders:     c.CorsAllowHeaders,
+		AllowCredentials: c.CorsAllowCredentials,
+		AllowOrigins:     c.CorsAllowOrigins,
+		MaxAge:           c.CorsMaxAge,
+	}
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cors config: %w", err)
+	}
+	return cfg, nil
+}
+
+// corsHandler is a cors middleware whose settings can be replaced while serving, a nil handler lets the
+// requests through.
+type corsHandler struct {
+	h atomic.Pointer[gin.HandlerFunc]
+}
+
+func (c *corsHandler) update(cfg *Config) error {
+	corsCfg, err := cfg.corsConfig()
+	if err != nil {
+		return err
+	}
+	if corsCfg == nil {
+		c.h.Store(nil)
+		return nil
+	}
+	h := cors.New(*corsCfg)
+	c.h.Store(&h)
+	return nil
+}
+
+func (c *corsHandler) handle(ctx *gin.Context) {
+	if h := c.h.Load(); h != nil {
+		(*h)(ctx)
+	}
 }
 
 func (c *Config) NewGinEngine() (*gin.Engine, gin.IRouter) {
+	var h corsHandler
+	if err := h.update(c); err != nil {
+		panic(err)
+	}
+	return c.newGinEngine(&h)
+}
+
+func (c *Config) newGinEngine(h *corsHandler) (*gin.Engine, gin.IRouter) {
 	if c.GinMode != "" {
 		gin.SetMode(c.GinMode)
 	}
 	r := gin.New()
+	r.Use(requestIDHandler)
+	if c.AccessLog {
+		r.Use(newAccessLogger(c).handle)
+	}
 	if c.EnableLogger {
 		r.Use(gin.Logger())
 	}
 	if c.EnableRecovery {
 		r.Use(gin.Recovery())
 	}
-	if c.EnableCORS {
-		r.Use(cors.New(cors.Config{
-			AllowMethods:     c.CorsAllowMethods,
-			AllowHeaders:     c.CorsAllowHeaders,
-			AllowCredentials: c.CorsAllowCredentials,
-			AllowOrigins:     c.CorsAllowOrigins,
-			MaxAge:           c.CorsMaxAge,
-		}))
-	}
+	// always installed so that cors can be enabled by reloading the config
+	r.Use(h.handle)
 	var i gin.IRouter = r
 	if c.ApiRoot != "" {
 		i = r.Group(c.ApiRoot)
@@ -6,22 +6,51 @@ import (
 	"github.com/aiechoic/admin/core/viper"
 )
 
+func init() {
+	viper.RegisterDefaultFunc(DefaultConfig, defaultConfig)
+}
+
 var Providers = ioc.NewProviders[*Server](func(name string, args ...any) *ioc.Provider[*Server] {
 	return ioc.NewProvider(func(c *ioc.Container) (*Server, error) {
-		vp, err := viper.GetViper(name, initConfig, c)
+		vp, err := viper.GetViper(name, defaultConfig(), c)
 		if err != nil {
 			return nil, err
 		}
 		var cfg Config
-		err = vp.Unmarshal(&cfg)
+		err = viper.Unmarshal(name, vp, &cfg)
+		if err != nil {
+			return nil, err
+		}
+		var cors corsHandler
+		err = cors.update(&cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config '%s': %w", name, err)
+		}
+		_, err = viper.OnConfigChange(name, c, cors.update)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg, certs, err := cfg.tlsConfig()
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal '%s' config: %w", name, err)
+			return nil, fmt.Errorf("config '%s': %w", name, err)
 		}
-		ginEngine, iRouter := cfg.NewGinEngine()
+		ginEngine, iRouter := cfg.newGinEngine(&cors)
 		return &Server{
-			Engine:    ginEngine,
-			ApiRouter: iRouter,
-			HttpPort:  cfg.HttpPort,
+			Engine:            ginEngine,
+			ApiRouter:         iRouter,
+			HttpPort:          cfg.HttpPort,
+			Listen:            cfg.Listen,
+			WarmupTimeout:     cfg.WarmupTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			ShutdownTimeout:   cfg.ShutdownTimeout,
+			ShutdownDelay:     cfg.ShutdownDelay,
+			TLSConfig:         tlsCfg,
+			HTTP2:             cfg.HTTP2,
+			RedirectHttpPort:  cfg.RedirectHttpPort,
+			certs:             certs,
 		}, nil
 	})
 })
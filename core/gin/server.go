@@ -2,50 +2,235 @@ package gin
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	"sync"
 	"time"
 )
 
+// DefaultShutdownTimeout is the default grace period given to the open connections when the server shuts
+// down.
+const DefaultShutdownTimeout = 5 * time.Second
+
+// ErrServerStarted is returned by Server.Start when the server is already started.
+var ErrServerStarted = errors.New("server already started")
+
 type Server struct {
 	Engine *gin.Engine
 	// api router for registering api routes
 	ApiRouter gin.IRouter
 	HttpPort  int
+	// address to listen on instead of HttpPort, such as "127.0.0.1:0" or "unix:/run/app.sock"
+	Listen string
+	// listener to serve on instead of Listen and HttpPort, for example one passed by systemd
+	Listener net.Listener
+	// maximum time for building the required providers before the server starts
+	WarmupTimeout time.Duration
+	// timeouts of the http server, 0 means no timeout
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// grace period given to the open connections when shutting down, DefaultShutdownTimeout if 0
+	ShutdownTimeout time.Duration
+	// time between calling the functions registered with RegisterOnShutdown and closing the listeners,
+	// the requests are still served meanwhile
+	ShutdownDelay time.Duration
+	// serve https if set
+	TLSConfig *tls.Config
+	// enable http/2, only used with tls
+	HTTP2 bool
+	// http port redirecting to https, 0 to disable, only used with tls
+	RedirectHttpPort int
+	// reloads the certificate of TLSConfig
+	certs *certReloader
+
+	onShutdown []func()
+	servers    []*http.Server
+	addr       net.Addr
+	serveErr   chan error
+	stopWatch  context.CancelFunc
+	mu         sync.Mutex
+}
+
+// RegisterOnShutdown registers a function to call as soon as the server starts shutting down gracefully,
+// before the open connections are drained, for example to fail the readiness checks.
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onShutdown = append(s.onShutdown, f)
 }
 
-func (s *Server) Run(ctx context.Context) {
-	address := fmt.Sprintf(":%d", s.HttpPort)
-	srv := &http.Server{
-		Addr:    address,
-		Handler: s.Engine,
+// listen returns the listener of the server, see Listener, Listen and HttpPort.
+func (s *Server) listen() (net.Listener, error) {
+	if s.Listener != nil {
+		return s.Listener, nil
 	}
+	if path, ok := strings.CutPrefix(s.Listen, "unix:"); ok {
+		// remove the socket left by a previous process
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return net.Listen("unix", path)
+	}
+	address := s.Listen
+	if address == "" {
+		address = fmt.Sprintf(":%d", s.HttpPort)
+	}
+	return net.Listen("tcp", address)
+}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("listen: %s\n", err)
+// Start listens and serves http requests in the background, it returns once the server accepts
+// connections or with the listen error. Use Addr to get the bound address and Shutdown to stop it.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.servers != nil {
+		return ErrServerStarted
+	}
+	ln, err := s.listen()
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	srv := s.newHTTPServer(s.Engine)
+	servers := []*http.Server{srv}
+	listeners := []net.Listener{ln}
+	if s.TLSConfig != nil {
+		srv.TLSConfig = s.TLSConfig
+		if !s.HTTP2 {
+			// a non-nil empty map disables http/2
+			srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+		if s.RedirectHttpPort != 0 {
+			redirectLn, err := net.Listen("tcp", fmt.Sprintf(":%d", s.RedirectHttpPort))
+			if err != nil {
+				ln.Close()
+				return fmt.Errorf("listen: %w", err)
+			}
+			// redirect to the bound port, which differs from HttpPort with Listen or Listener
+			httpsPort := s.HttpPort
+			if addr, ok := ln.Addr().(*net.TCPAddr); ok {
+				httpsPort = addr.Port
+			}
+			servers = append(servers, s.newHTTPServer(redirectHandler(httpsPort)))
+			listeners = append(listeners, redirectLn)
 		}
-	}()
+	}
+	if s.certs != nil {
+		// the watch outlives the start context, it is stopped by Shutdown
+		var watchCtx context.Context
+		watchCtx, s.stopWatch = context.WithCancel(context.WithoutCancel(ctx))
+		go s.certs.watch(watchCtx)
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	s.serveErr = make(chan error, len(servers))
+	for i, srv := range servers {
+		go func(srv *http.Server, ln net.Listener, useTLS bool) {
+			var err error
+			if useTLS {
+				// the certificate is served by TLSConfig
+				err = srv.ServeTLS(ln, "", "")
+			} else {
+				err = srv.Serve(ln)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.serveErr <- err
+			}
+		}(srv, listeners[i], i == 0 && s.TLSConfig != nil)
+	}
+	s.servers = servers
+	s.addr = ln.Addr()
+	return nil
+}
+
+func (s *Server) newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadTimeout:       s.ReadTimeout,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+	}
+}
+
+// Addr returns the address the server listens on, nil if it is not started. It reports the port chosen by
+// the system when listening on port 0.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// Shutdown calls the functions registered with RegisterOnShutdown, waits for the ShutdownDelay, then stops
+// accepting connections and waits for the open connections to finish until ctx is done. It does nothing if
+// the server is not started.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	servers := s.servers
+	onShutdown := s.onShutdown
+	stopWatch := s.stopWatch
+	s.servers, s.addr, s.stopWatch = nil, nil, nil
+	s.mu.Unlock()
+	if servers == nil {
+		return nil
+	}
+	if stopWatch != nil {
+		stopWatch()
+	}
+	for _, f := range onShutdown {
+		f()
+	}
+	if s.ShutdownDelay > 0 {
+		timer := time.NewTimer(s.ShutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("server forced to shutdown: %w", err)
+	}
+	return nil
+}
+
+// Run starts the server and serves http requests until ctx is cancelled, then shuts the server down
+// gracefully within the ShutdownDelay plus the ShutdownTimeout. It returns an error if the server can not listen, fails while
+// serving or can not shut down in time. The server is a ioc.Runner, it is run by ioc.Container.Run.
+func (s *Server) Run(ctx context.Context) error {
+	err := s.Start(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	serveErr := s.serveErr
+	s.mu.Unlock()
 
 	select {
-	case <-quit:
-		log.Println("received system signal, shutting down gracefully")
+	case err = <-serveErr:
+		err = fmt.Errorf("serve: %w", err)
 	case <-ctx.Done():
 		log.Println("context cancelled, shutting down gracefully")
 	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Fatal("server forced to shutdown:", err)
+	timeout := s.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
 	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownDelay+timeout)
+	defer cancel()
+	return errors.Join(err, s.Shutdown(shutdownCtx))
 }
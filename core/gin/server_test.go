@@ -0,0 +1,94 @@
+package gin
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestServer() *Server {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return &Server{Engine: engine, ApiRouter: engine}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	rsp, err := client.Get(url)
+	assert.NoError(t, err)
+	defer rsp.Body.Close()
+	data, err := io.ReadAll(rsp.Body)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestServer_Start(t *testing.T) {
+	s := newTestServer()
+	s.Listen = "127.0.0.1:0"
+	var shutdown bool
+	s.RegisterOnShutdown(func() { shutdown = true })
+
+	assert.Nil(t, s.Addr())
+	assert.NoError(t, s.Start(context.Background()))
+	assert.ErrorIs(t, s.Start(context.Background()), ErrServerStarted)
+	addr := s.Addr()
+	assert.NotNil(t, addr)
+	assert.Equal(t, "pong", get(t, http.DefaultClient, "http://"+addr.String()+"/ping"))
+
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assert.True(t, shutdown)
+	assert.Nil(t, s.Addr())
+	_, err := http.Get("http://" + addr.String() + "/ping")
+	assert.Error(t, err)
+
+	// shutting down a stopped server does nothing
+	assert.NoError(t, s.Shutdown(context.Background()))
+}
+
+func TestServer_Start_unix(t *testing.T) {
+	s := newTestServer()
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	s.Listen = "unix:" + socket
+	assert.NoError(t, s.Start(context.Background()))
+	defer s.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	assert.Equal(t, "pong", get(t, client, "http://app/ping"))
+}
+
+func TestServer_Run(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := newTestServer()
+	s.Listener = ln
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	assert.Eventually(t, func() bool { return s.Addr() != nil }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "pong", get(t, http.DefaultClient, "http://"+ln.Addr().String()+"/ping"))
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	// listen errors are returned
+	taken := newTestServer()
+	taken.Listen = "127.0.0.1:0"
+	assert.NoError(t, taken.Start(context.Background()))
+	defer taken.Shutdown(context.Background())
+	s = newTestServer()
+	s.Listen = taken.Addr().String()
+	assert.ErrorContains(t, s.Run(context.Background()), "listen: ")
+}
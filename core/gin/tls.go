@@ -0,0 +1,134 @@
+package gin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsConfig returns the tls settings of the server, or nil if the certificate is not set.
+func (c *Config) tlsConfig() (*tls.Config, *certReloader, error) {
+	if c.TLSCertFile == "" {
+		return nil, nil, nil
+	}
+	certs := &certReloader{certFile: c.TLSCertFile, keyFile: c.TLSKeyFile}
+	err := certs.reload()
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.getCertificate,
+	}
+	if c.TLSMinVersion != "" {
+		cfg.MinVersion = tlsVersions[c.TLSMinVersion]
+	}
+	if c.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(c.TLSClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificate found in client ca file \"%s\"", c.TLSClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, certs, nil
+}
+
+// certReloader serves the certificate loaded from files, it is reloaded when the files change or the
+// process receives SIGHUP. The connections keep the certificate they were established with.
+type certReloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading tls certificate: %w", err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// watch reloads the certificate until ctx is done, a failed reload keeps the previous certificate.
+func (r *certReloader) watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("tls: watching certificate files: %v, reloading on SIGHUP only\n", err)
+	} else {
+		defer watcher.Close()
+		// watch the directories, the files are usually replaced rather than written
+		for _, dir := range []string{filepath.Dir(r.certFile), filepath.Dir(r.keyFile)} {
+			if err := watcher.Add(dir); err != nil {
+				log.Printf("tls: watching certificate files: %v\n", err)
+			}
+		}
+		events = watcher.Events
+	}
+	files := map[string]bool{filepath.Clean(r.certFile): true, filepath.Clean(r.keyFile): true}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if !files[filepath.Clean(event.Name)] || event.Has(fsnotify.Chmod) {
+				continue
+			}
+		}
+		if err := r.reload(); err != nil {
+			log.Printf("tls: %v, keeping the previous certificate\n", err)
+			continue
+		}
+		log.Println("tls: certificate reloaded")
+	}
+}
+
+// redirectHandler redirects the http requests to the https port.
+func redirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
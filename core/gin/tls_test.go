@@ -0,0 +1,110 @@
+package gin
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for the common name and returns the certificate and key files.
+func writeCert(t *testing.T, dir, cn string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *certReloader) string {
+	cert, err := r.getCertificate(nil)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_watch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "day-1")
+	cfg := &Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSMinVersion: "1.3"}
+	tlsCfg, certs, err := cfg.tlsConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x0304), tlsCfg.MinVersion)
+	assert.Equal(t, "day-1", commonName(t, certs))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go certs.watch(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	writeCert(t, dir, "day-2")
+	assert.Eventually(t, func() bool { return commonName(t, certs) == "day-2" }, 5*time.Second, 50*time.Millisecond)
+
+	// a broken certificate keeps the previous one
+	assert.NoError(t, os.WriteFile(certFile, []byte("broken"), 0600))
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, "day-2", commonName(t, certs))
+}
+
+func TestRedirectHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	redirectHandler(8443).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com:8080/api/v1/users?page=2", nil))
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "https://example.com:8443/api/v1/users?page=2", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	redirectHandler(443).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	assert.Equal(t, "https://example.com/", w.Header().Get("Location"))
+}
+
+func TestServer_Start_redirect(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "localhost")
+	tlsCfg, _, err := (&Config{TLSCertFile: certFile, TLSKeyFile: keyFile}).tlsConfig()
+	assert.NoError(t, err)
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	redirectPort := free.Addr().(*net.TCPAddr).Port
+	assert.NoError(t, free.Close())
+
+	s := newTestServer()
+	s.Listen = "127.0.0.1:0"
+	s.HttpPort = 8443
+	s.TLSConfig = tlsCfg
+	s.RedirectHttpPort = redirectPort
+	assert.NoError(t, s.Start(context.Background()))
+	defer s.Shutdown(context.Background())
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	rsp, err := client.Get(fmt.Sprintf("http://localhost:%d/ping", redirectPort))
+	assert.NoError(t, err)
+	rsp.Body.Close()
+	port := s.Addr().(*net.TCPAddr).Port
+	assert.Equal(t, fmt.Sprintf("https://localhost:%d/ping", port), rsp.Header.Get("Location"))
+}
@@ -0,0 +1,28 @@
+package gins
+
+import (
+	"github.com/aiechoic/admin/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+var requestDuration = metrics.NewHistogram(
+	"http_request_duration_seconds",
+	"Duration of the http requests by service tag and route template.",
+	metrics.DefBuckets,
+	"tag", "method", "route", "status",
+)
+
+// observe records the duration of the requests of a route, the route template is used rather than the
+// raw path to keep the number of series bounded.
+func observe(tag string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		requestDuration.Observe(
+			time.Since(start).Seconds(),
+			tag, c.Request.Method, c.FullPath(), strconv.Itoa(c.Writer.Status()),
+		)
+	}
+}
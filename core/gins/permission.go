@@ -3,14 +3,15 @@ package gins
 import (
 	"crypto/sha256"
 	"fmt"
+	"github.com/aiechoic/admin/core/ioc"
 	"github.com/gin-gonic/gin"
 	"reflect"
 	"runtime"
+	"sync"
 )
 
-var authHandlerPermissions = map[uintptr]*Permission{}
-
-var sortHandlerPermissions = map[string][]*Permission{}
+// permissionsKey is the gin context key of the permission registry of the server handling the request.
+const permissionsKey = "gins.permissions"
 
 type Permission struct {
 	Tag    string
@@ -19,21 +20,71 @@ type Permission struct {
 	Code   string // hash of the method and path
 }
 
-func setHandlerPermission(fn gin.HandlerFunc, p *Permission) {
+// Permissions is the permission registry of an APIServer, it records the permission of each secured route.
+// The permissions of a server are listed by APIServer.Permissions.All, the servers may share service tags.
+type Permissions struct {
+	byHandler map[uintptr]*Permission
+	byTag     map[string][]*Permission
+	mu        sync.RWMutex
+}
+
+func newPermissions() *Permissions {
+	return &Permissions{
+		byHandler: map[uintptr]*Permission{},
+		byTag:     map[string][]*Permission{},
+	}
+}
+
+// PermissionProviders defines the permission registries of the api servers, by server name. The registries
+// are kept by the container, so they are released with it.
+var PermissionProviders = ioc.NewProviders(func(name string, args ...any) *ioc.Provider[*Permissions] {
+	return ioc.NewProvider(func(c *ioc.Container) (*Permissions, error) {
+		return newPermissions(), nil
+	})
+})
+
+// GetPermissions returns the permission registry of the named api server.
+func GetPermissions(name string, c *ioc.Container) (*Permissions, error) {
+	return PermissionProviders.GetProvider(name).Get(c)
+}
+
+func (ps *Permissions) set(fn gin.HandlerFunc, p *Permission) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
 	pointer := reflect.ValueOf(fn).Pointer()
-	if _, ok := authHandlerPermissions[pointer]; ok {
+	if _, ok := ps.byHandler[pointer]; ok {
 		panic(fmt.Sprintf("handler %s already exist", runtime.FuncForPC(pointer).Name()))
 	}
-	authHandlerPermissions[pointer] = p
-	sortHandlerPermissions[p.Tag] = append(sortHandlerPermissions[p.Tag], p)
+	ps.byHandler[pointer] = p
+	ps.byTag[p.Tag] = append(ps.byTag[p.Tag], p)
 }
 
-func GetHandlerPermission(c *gin.Context) *Permission {
-	return authHandlerPermissions[reflect.ValueOf(c.Handler()).Pointer()]
+// Get returns the permission of the handler of the request, nil if the route is not secured.
+func (ps *Permissions) Get(c *gin.Context) *Permission {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.byHandler[reflect.ValueOf(c.Handler()).Pointer()]
 }
 
-func GetAllPermissions() map[string][]*Permission {
-	return sortHandlerPermissions
+// All returns the permissions grouped by service tag.
+func (ps *Permissions) All() map[string][]*Permission {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	all := make(map[string][]*Permission, len(ps.byTag))
+	for tag, pms := range ps.byTag {
+		all[tag] = append([]*Permission(nil), pms...)
+	}
+	return all
+}
+
+// GetHandlerPermission returns the permission of the handler of the request in the registry of the server
+// handling it, nil if the route is not secured.
+func GetHandlerPermission(c *gin.Context) *Permission {
+	ps, ok := c.Get(permissionsKey)
+	if !ok {
+		return nil
+	}
+	return ps.(*Permissions).Get(c)
 }
 
 func getStringHash(s string) string {
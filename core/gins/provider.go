@@ -1,9 +1,10 @@
 package gins
 
 import (
-	"github.com/aiechoic/admin/core/gin"
+	engin "github.com/aiechoic/admin/core/gin"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/openapi"
+	"github.com/gin-gonic/gin"
 )
 
 var Providers = ioc.NewProviders(func(name string, args ...string) *ioc.Provider[*APIServer] {
@@ -14,21 +15,43 @@ var Providers = ioc.NewProviders(func(name string, args ...string) *ioc.Provider
 		if err != nil {
 			return nil, err
 		}
-		engine, err := gin.GetServer(ginConfig, c)
+		engine, err := engin.GetServer(ginConfig, c)
 		if err != nil {
 			return nil, err
 		}
+		permissions, err := GetPermissions(name, c)
+		if err != nil {
+			return nil, err
+		}
+		engine.ApiRouter.Use(Scope(c), func(ctx *gin.Context) {
+			ctx.Set(permissionsKey, permissions)
+		})
 		return &APIServer{
-			API:   api,
-			Engin: engine,
+			Name:        name,
+			API:         api,
+			Engin:       engine,
+			Permissions: permissions,
+			container:   c,
 		}, nil
 	})
 })
 
+// GetAPIServer returns the api server serving the routes on the engine configured by ginConfig and
+// documenting them in the spec configured by openapiConfig. The servers are named by their gin config, so
+// several servers, such as a public and an admin api, run on their own ports with their own specs:
+//
+//	public, _ := gins.GetAPIServer("gin-server", "openapi", c)
+//	admin, _ := gins.GetAPIServer("admin-server", "admin-openapi", c)
+//
+// An error is returned if the server of ginConfig is already documented by another openapi config.
 func GetAPIServer(ginConfig, openapiConfig string, c *ioc.Container) (*APIServer, error) {
-	return Providers.GetProvider("", ginConfig, openapiConfig).Get(c)
+	p, err := Providers.Lookup(ginConfig, ginConfig, openapiConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(c)
 }
 
 func GetDefaultAPIServer(c *ioc.Container) (*APIServer, error) {
-	return GetAPIServer(gin.DefaultConfig, openapi.DefaultConfig, c)
+	return GetAPIServer(engin.DefaultConfig, openapi.DefaultConfig, c)
 }
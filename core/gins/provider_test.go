@@ -0,0 +1,108 @@
+package gins
+
+import (
+	"context"
+	"errors"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/openapi"
+	"github.com/aiechoic/admin/core/viper"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSecurity struct{}
+
+func (testSecurity) Auth(c *gin.Context) {}
+
+func (testSecurity) SecuritySchemes() openapi.SecuritySchemes {
+	return openapi.SecuritySchemes{}
+}
+
+func (testSecurity) SecurityRequirement() map[string][]string {
+	return map[string][]string{"test": {}}
+}
+
+func TestGetAPIServer_named(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(t.TempDir(), viper.Testing)))
+
+	public, err := GetAPIServer("public-server", "public-openapi", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := GetAPIServer("admin-server", "admin-openapi", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.Engin == admin.Engin || public.API == admin.API {
+		t.Fatal("the servers should have their own engine and spec")
+	}
+	if _, err = GetAPIServer("admin-server", "public-openapi", c); err == nil {
+		t.Error("a server should be documented by a single spec")
+	}
+
+	var got *Permission
+	handle := func(c *gin.Context) {
+		got = GetHandlerPermission(c)
+	}
+	// the same handler is registered on both servers
+	public.Register(&Service{Tag: "Users", Path: "/users", Security: testSecurity{}, Routes: []Route{
+		{Method: "GET", Path: "", Handler: Handler{Handle: handle}},
+	}})
+	admin.Register(&Service{Tag: "Admin", Path: "/admins", Security: testSecurity{}, Routes: []Route{
+		{Method: "GET", Path: "", Handler: Handler{Handle: handle}},
+	}})
+	if len(public.API.Paths) != 1 || len(admin.API.Paths) != 1 {
+		t.Errorf("each spec should only document the routes of its server")
+	}
+
+	admin.Engin.Engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/admins", nil))
+	if got == nil || got.Tag != "Admin" {
+		t.Errorf("permission = %+v, want the Admin permission", got)
+	}
+	if len(public.Permissions.All()["Admin"]) != 0 {
+		t.Errorf("the permission registries should be separate")
+	}
+	if ps, err := GetPermissions("admin-server", c); err != nil || ps != admin.Permissions {
+		t.Errorf("the registry of the server should be kept by the container")
+	}
+
+	// the servers are started and shut down together, the api servers are components of the container
+	public.Engin.Listen, admin.Engin.Listen = "127.0.0.1:0", "127.0.0.1:0"
+	done := make(chan error)
+	go func() { done <- public.Run(context.Background()) }()
+	deadline := time.Now().Add(time.Second)
+	for public.Engin.Addr() == nil || admin.Engin.Addr() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("servers not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if public.Engin.Addr() != nil || admin.Engin.Addr() != nil {
+		t.Error("servers not shut down")
+	}
+}
+
+func TestAPIServer_RunWarmupFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(t.TempDir(), viper.Testing)))
+	server, err := GetDefaultAPIServer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Require(ioc.NewProvider(func(c *ioc.Container) (*testSecurity, error) {
+		return nil, errors.New("store unavailable")
+	}))
+	err = server.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "store unavailable") {
+		t.Errorf("err = %v, want the warmup error", err)
+	}
+}
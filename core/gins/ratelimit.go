@@ -0,0 +1,130 @@
+package gins
+
+import (
+	"fmt"
+	"github.com/aiechoic/admin/core/jwt"
+	"github.com/aiechoic/admin/core/openapi"
+	"github.com/aiechoic/admin/core/ratelimit"
+	"github.com/aiechoic/admin/pkg/errs"
+	"github.com/aiechoic/admin/pkg/rsp"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc returns the key of the client of a request, the requests of the same client share the limit.
+type KeyFunc func(c *gin.Context) string
+
+// RateLimitByIP keys the requests by the client ip.
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByUser keys the requests by the identity of the jwt user, see jwt.Identifier, the anonymous
+// requests are keyed by the client ip.
+func RateLimitByUser(c *gin.Context) string {
+	if id := c.GetString(jwt.IdentityKey); id != "" {
+		return "user:" + id
+	}
+	return RateLimitByIP(c)
+}
+
+// RateLimit is a rate limit policy allowing Limit requests per client in any Window, the requests over
+// the limit are rejected with 429 Too Many Requests. The policy of a service applies to each of its routes
+// separately.
+//
+//	Route{
+//		Method:    "POST",
+//		Path:      "/login",
+//		RateLimit: &gins.RateLimit{Limit: 5, Window: time.Minute},
+//	}
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+	// key of the client, RateLimitByIP if nil
+	Key KeyFunc
+	// limiter counting the requests, the limiter of the api server if nil
+	Limiter ratelimit.Limiter
+}
+
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+// seconds rounds d up to whole seconds, as used by the rate limit headers.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// handler enforces the policy with the limiter returned by getLimiter on the requests of the route
+// identified by scope. The requests are let through when the limiter can not be created or fails, so that
+// an unavailable redis does not take the api down.
+func (p *RateLimit) handler(scope string, getLimiter func() (ratelimit.Limiter, error)) gin.HandlerFunc {
+	if p.Limit <= 0 || p.Window <= 0 {
+		panic(fmt.Sprintf("route %s: rate limit requires a positive limit and window", scope))
+	}
+	key := p.Key
+	if key == nil {
+		key = RateLimitByIP
+	}
+	return func(c *gin.Context) {
+		limiter, err := getLimiter()
+		if err != nil {
+			log.Printf("rate limit %s: %v", scope, err)
+			return
+		}
+		result, err := limiter.Allow(c.Request.Context(), scope+":"+key(c), p.Limit, p.Window)
+		if err != nil {
+			log.Printf("rate limit %s: %v", scope, err)
+			return
+		}
+		c.Header(headerRateLimitLimit, strconv.Itoa(result.Limit))
+		c.Header(headerRateLimitRemaining, strconv.Itoa(result.Remaining))
+		c.Header(headerRateLimitReset, seconds(result.Reset))
+		if !result.Allowed {
+			c.Header(headerRetryAfter, seconds(max(result.Reset, time.Second)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, rsp.Response{
+				Success:   false,
+				Error:     errs.TooManyRequests.Error(),
+				Code:      errs.TooManyRequests,
+				RequestID: c.GetString(rsp.RequestIDKey),
+			})
+		}
+	}
+}
+
+func rateLimitHeaders() map[string]*openapi.Header {
+	integer := &openapi.Schema{Type: "integer"}
+	return map[string]*openapi.Header{
+		headerRateLimitLimit:     {Description: "Maximum number of requests in the window", Schema: integer},
+		headerRateLimitRemaining: {Description: "Number of requests left in the window", Schema: integer},
+		headerRateLimitReset:     {Description: "Seconds until a request of the window expires", Schema: integer},
+	}
+}
+
+// document adds the rate limit headers and the 429 response to the operation.
+func (p *RateLimit) document(o *openapi.Openapi, op *openapi.Operation) {
+	for _, response := range op.Responses {
+		response.Headers = rateLimitHeaders()
+	}
+	schema, refs := openapi.NewSchema(rsp.Response{}, "json")
+	o.AddComponentsSchemas(refs)
+	headers := rateLimitHeaders()
+	headers[headerRetryAfter] = &openapi.Header{
+		Description: "Seconds to wait before retrying",
+		Schema:      &openapi.Schema{Type: "integer"},
+	}
+	op.Responses["429"] = &openapi.ResponseBody{
+		Description: fmt.Sprintf("Too many requests, at most %d per %s", p.Limit, p.Window),
+		Headers:     headers,
+		Content: map[openapi.ContentType]*openapi.MediaType{
+			openapi.ContentTypeJson: {Schema: schema},
+		},
+	}
+}
@@ -0,0 +1,155 @@
+package gins
+
+import (
+	"errors"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/jwt"
+	"github.com/aiechoic/admin/core/ratelimit"
+	"github.com/aiechoic/admin/core/viper"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(t.TempDir(), viper.Testing)))
+	server, err := GetDefaultAPIServer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Limiter = ratelimit.NewMemoryLimiter()
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	server.Register(&Service{
+		Tag:       "Auth",
+		Path:      "/auth",
+		RateLimit: &RateLimit{Limit: 2, Window: time.Minute},
+		Routes: []Route{
+			{Method: "POST", Path: "login", Handler: Handler{Handle: ok}},
+			{Method: "POST", Path: "code", Handler: Handler{Handle: ok}},
+		},
+	})
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.Engin.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		w := do("/api/v1/auth/login")
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != remaining {
+			t.Fatalf("request %d: status = %d, remaining = %s", i, w.Code, w.Header().Get("X-RateLimit-Remaining"))
+		}
+	}
+	w := do("/api/v1/auth/login")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", w.Code)
+	}
+	if retry := w.Header().Get("Retry-After"); retry == "" || retry == "0" {
+		t.Errorf("Retry-After = %q, want the seconds until the window frees", retry)
+	}
+	// the routes of the service are limited separately
+	if w = do("/api/v1/auth/code"); w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+
+	op := server.API.Paths["/auth/login"]["post"]
+	if op.Responses["429"] == nil || op.Responses["429"].Headers["Retry-After"] == nil {
+		t.Errorf("the 429 response should be documented")
+	}
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := &RateLimit{Limit: 1, Window: time.Minute, Key: RateLimitByUser, Limiter: ratelimit.NewMemoryLimiter()}
+	r := gin.New()
+	r.GET("/profile", func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set(jwt.IdentityKey, user)
+		}
+	}, p.handler("GET /profile", (&APIServer{}).limiter(p)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, test := range []struct {
+		user string
+		code int
+	}{
+		{"alice", http.StatusOK},
+		{"alice", http.StatusTooManyRequests},
+		{"bob", http.StatusOK},
+		{"", http.StatusOK},
+		{"", http.StatusTooManyRequests},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("X-User", test.user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("user %q: status = %d, want %d", test.user, w.Code, test.code)
+		}
+	}
+}
+
+var unavailableBuilds atomic.Int32
+
+func init() {
+	ioc.RegisterConfigDriver("test-unavailable", func(c *ioc.Container, cfg *ratelimit.Config) (ratelimit.Limiter, error) {
+		unavailableBuilds.Add(1)
+		return nil, errors.New("store unavailable")
+	})
+}
+
+func TestRateLimit_limiterUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "testing"), 0755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "testing", ratelimit.DefaultConfig+".yaml"), []byte(`driver: "test-unavailable"`), 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(dir, viper.Testing)))
+	server, err := GetDefaultAPIServer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the limiter can not be created, the requests are let through
+	server.Register(&Service{
+		Tag:       "Auth",
+		Path:      "/auth",
+		RateLimit: &RateLimit{Limit: 1, Window: time.Minute},
+		Routes: []Route{
+			{Method: "POST", Path: "login", Handler: Handler{Handle: func(c *gin.Context) { c.Status(http.StatusOK) }}},
+		},
+	})
+	do := func() {
+		w := httptest.NewRecorder()
+		server.Engin.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", w.Code)
+		}
+	}
+	unavailableBuilds.Store(0)
+	for i := 0; i < 3; i++ {
+		do()
+	}
+	// the failure is kept until the retry delay elapses
+	if n := unavailableBuilds.Load(); n != 1 {
+		t.Errorf("limiter created %d times, want 1", n)
+	}
+	server.limiterRetry = time.Time{}
+	do()
+	if n := unavailableBuilds.Load(); n != 2 {
+		t.Errorf("limiter created %d times, want 2 after the retry delay", n)
+	}
+}
@@ -2,18 +2,41 @@ package gins
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	engin "github.com/aiechoic/admin/core/gin"
+	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/openapi"
+	"github.com/aiechoic/admin/core/ratelimit"
 	"github.com/gin-gonic/gin"
 	"strings"
+	"sync"
+	"time"
 )
 
 type APIServer struct {
+	// name of the server, the name of its gin config
+	Name  string
 	API   *openapi.Openapi
 	Engin *engin.Server
+	// permissions of the secured routes registered on the server
+	Permissions *Permissions
+	// limiter counting the requests of the rate limited routes, the default ratelimit limiter is used if nil
+	Limiter ratelimit.Limiter
+	// state of the creation of the default limiter, see defaultLimiter
+	limiterMu       sync.Mutex
+	limiterBuilding bool
+	limiterErr      error
+	limiterRetry    time.Time
+	container       *ioc.Container
 }
 
+// limiterRetryDelay is the time after which the creation of the default limiter is retried after a
+// failure, the rate limited requests are let through meanwhile.
+const limiterRetryDelay = 10 * time.Second
+
+var errLimiterPending = errors.New("rate limiter is being created")
+
 func (s *APIServer) Register(services ...*Service) {
 	for _, service := range services {
 		s.register(service)
@@ -108,8 +131,8 @@ func (s *APIServer) register(service *Service) {
 		}
 		pathItem[route.Method] = op
 
-		// add security
-		var handlers []gin.HandlerFunc
+		// add security, the rejected requests are observed too
+		handlers := []gin.HandlerFunc{observe(service.Tag)}
 		if route.Security == nil && service.Security != nil {
 			route.Security = service.Security
 		}
@@ -129,9 +152,19 @@ func (s *APIServer) register(service *Service) {
 				Path:   swaggerPath,
 				Code:   getStringHash(route.Method + swaggerPath)[0:8],
 			}
-			setHandlerPermission(route.Handler.Handle, pms)
+			s.Permissions.set(route.Handler.Handle, pms)
 			op.Summary += fmt.Sprintf(" (permission: %s)", pms.Code)
 		}
+
+		// add rate limit after the security, so that the requests can be keyed by user
+		if route.RateLimit == nil {
+			route.RateLimit = service.RateLimit
+		}
+		if route.RateLimit != nil {
+			scope := strings.ToUpper(route.Method) + " " + fullPath
+			handlers = append(handlers, route.RateLimit.handler(scope, s.limiter(route.RateLimit)))
+			route.RateLimit.document(o, op)
+		}
 		handlers = append(handlers, route.Handler.Handle)
 
 		// register route with gin
@@ -139,6 +172,70 @@ func (s *APIServer) register(service *Service) {
 	}
 }
 
-func (s *APIServer) Run(ctx context.Context) {
-	s.Engin.Run(ctx)
+// limiter returns the limiter of the policy, or the limiter of the api server if the policy has none.
+func (s *APIServer) limiter(p *RateLimit) func() (ratelimit.Limiter, error) {
+	if p.Limiter != nil {
+		return func() (ratelimit.Limiter, error) {
+			return p.Limiter, nil
+		}
+	}
+	return s.defaultLimiter
+}
+
+// defaultLimiter returns the limiter of the api server, the default limiter is created on the first rate
+// limited request, so that the servers without rate limits do not require its store and an unavailable
+// store does not prevent the server from starting. A single request creates it, the others get an error
+// meanwhile, and a failure is returned without retrying for limiterRetryDelay.
+func (s *APIServer) defaultLimiter() (ratelimit.Limiter, error) {
+	s.limiterMu.Lock()
+	if s.Limiter != nil {
+		defer s.limiterMu.Unlock()
+		return s.Limiter, nil
+	}
+	if s.limiterBuilding {
+		s.limiterMu.Unlock()
+		return nil, errLimiterPending
+	}
+	if time.Now().Before(s.limiterRetry) {
+		defer s.limiterMu.Unlock()
+		return nil, s.limiterErr
+	}
+	s.limiterBuilding = true
+	s.limiterMu.Unlock()
+
+	limiter, err := ratelimit.GetDefaultLimiter(s.container)
+
+	s.limiterMu.Lock()
+	defer s.limiterMu.Unlock()
+	s.limiterBuilding = false
+	if err != nil {
+		s.limiterErr, s.limiterRetry = err, time.Now().Add(limiterRetryDelay)
+		return nil, err
+	}
+	s.Limiter = limiter
+	return limiter, nil
+}
+
+// Run runs the http server together with the other components of the container, such as background
+// workers, until ctx is cancelled or a termination signal is received, see ioc.Container.Run. All the
+// servers of the container are run, started and shut down together, so Run is called on one of them only.
+//
+// The providers declared with ioc.Container.Require are built first, the server refuses to start if
+// any of them fails. The error of the warmup or of the run is returned.
+func (s *APIServer) Run(ctx context.Context) error {
+	if s.container.Running(ctx) {
+		// the server is a component of the running container, its engine is run by the container
+		return nil
+	}
+	timeout := s.Engin.WarmupTimeout
+	if timeout <= 0 {
+		timeout = ioc.DefaultWarmupTimeout
+	}
+	warmupCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := s.container.Warmup(warmupCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("warmup failed, refusing to start:\n%w", err)
+	}
+	return s.container.Run(ctx)
 }
@@ -0,0 +1,30 @@
+package gins
+
+import (
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+const scopeKey = "gins.ioc.scope"
+
+// Scope returns a middleware which opens a child container of c for every request, the child is stored
+// in the gin.Context and closed when the request is finished, see GetScope.
+func Scope(c *ioc.Container) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scope := c.Child()
+		defer func() {
+			if err := scope.Close(); err != nil {
+				log.Printf("close request scope: %s\n", err)
+			}
+		}()
+		ctx.Set(scopeKey, scope)
+		ctx.Next()
+	}
+}
+
+// GetScope returns the request scoped container opened by the Scope middleware, scoped providers
+// resolved from it live as long as the request. It panics if the Scope middleware is not installed.
+func GetScope(ctx *gin.Context) *ioc.Container {
+	return ctx.MustGet(scopeKey).(*ioc.Container)
+}
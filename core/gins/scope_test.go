@@ -0,0 +1,54 @@
+package gins
+
+import (
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type requestCloser struct {
+	closed bool
+}
+
+func (r *requestCloser) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := ioc.NewContainer()
+	provider := ioc.NewScopedProvider(func(c *ioc.Container) (*requestCloser, error) {
+		return &requestCloser{}, nil
+	})
+
+	var got []*requestCloser
+	r := gin.New()
+	r.Use(Scope(c))
+	r.GET("/", func(ctx *gin.Context) {
+		scope := GetScope(ctx)
+		ins := provider.MustGet(scope)
+		if provider.MustGet(scope) != ins {
+			t.Error("scoped instance should be reused within a request")
+		}
+		got = append(got, ins)
+	})
+
+	for i := 0; i < 2; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if len(got) != 2 || got[0] == got[1] {
+		t.Fatalf("each request should get its own instance")
+	}
+	for _, ins := range got {
+		if !ins.closed {
+			t.Errorf("scoped instance should be closed after the request")
+		}
+	}
+	if provider.IsSet(c) {
+		t.Errorf("scoped instance should not be stored in the root container")
+	}
+}
@@ -182,7 +182,9 @@ type Route struct {
 	Description string
 	Deprecated  bool
 	Security    Security
-	Handler     Handler
+	// rate limit of the route, the rate limit of the service if nil
+	RateLimit *RateLimit
+	Handler   Handler
 }
 
 type Handler struct {
@@ -201,5 +203,7 @@ type Service struct {
 	Description string
 	Path        string
 	Security    Security
-	Routes      []Route
+	// rate limit applied to each route of the service without its own rate limit
+	RateLimit *RateLimit
+	Routes    []Route
 }
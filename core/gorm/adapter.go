@@ -0,0 +1,167 @@
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aiechoic/admin/core/viper"
+	spf13 "github.com/spf13/viper"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// SeedAuthor is the author of the config versions seeded from the initial configurations.
+const SeedAuthor = "system"
+
+// ConfigVersion is a version of a config document stored by ConfigAdapter, the latest version of a name
+// is the one in use.
+type ConfigVersion struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Env         string    `gorm:"size:64;uniqueIndex:idx_config_version" json:"env"`
+	Name        string    `gorm:"size:128;uniqueIndex:idx_config_version" json:"name"`
+	Version     int       `gorm:"uniqueIndex:idx_config_version" json:"version"`
+	ContentType string    `gorm:"size:16" json:"content_type"`
+	Content     string    `gorm:"type:text" json:"content"`
+	Author      string    `gorm:"size:128" json:"author"`
+	Comment     string    `gorm:"size:255" json:"comment"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ConfigAdapter is a viper.Adapter implementation that stores the configuration documents in a database
+// table, so that they can be edited while the application runs. The documents are seeded from the initial
+// configurations and every change is kept as a new version which can be rolled back. The changes are
+// published to the viper.OnChange subscribers of the containers using the adapter, the other instances read them
+// when they restart.
+//
+//	db, _ := gorm.Open(sqlite.Open("configs.db"))
+//	adapter, _ := gorm.NewConfigAdapter(db, viper.ActiveEnv())
+//	viper.SetAdapter(adapter)
+type ConfigAdapter struct {
+	viper.Subscriptions
+	db  *gorm.DB
+	env viper.Env
+}
+
+// NewConfigAdapter creates the config table if needed and returns an adapter reading the documents of env.
+func NewConfigAdapter(db *gorm.DB, env viper.Env) (*ConfigAdapter, error) {
+	err := db.AutoMigrate(&ConfigVersion{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config table: %w", err)
+	}
+	return &ConfigAdapter{db: db, env: env}, nil
+}
+
+// NewViper implements the viper.Adapter interface. If the config has no version yet, the initial
+// configuration is stored as version 1.
+func (a *ConfigAdapter) NewViper(name, ext, initConfig string) (*spf13.Viper, error) {
+	current, err := a.Current(name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		current, err = a.create(a.db, name, ext, initConfig, SeedAuthor, "initial config")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newConfigViper(current)
+}
+
+// Current returns the latest version of the named config, gorm.ErrRecordNotFound if there is none.
+func (a *ConfigAdapter) Current(name string) (*ConfigVersion, error) {
+	return a.current(a.db, name)
+}
+
+func (a *ConfigAdapter) current(tx *gorm.DB, name string) (*ConfigVersion, error) {
+	var v ConfigVersion
+	err := tx.Where("env = ? AND name = ?", a.env, name).Order("version DESC").First(&v).Error
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Versions returns the versions of the named config, the latest first.
+func (a *ConfigAdapter) Versions(name string) ([]ConfigVersion, error) {
+	var vs []ConfigVersion
+	err := a.db.Where("env = ? AND name = ?", a.env, name).Order("version DESC").Find(&vs).Error
+	return vs, err
+}
+
+// Update stores content as the new version of the named config and publishes it to the viper.OnChange
+// subscribers once stored. The config must exist and content must have its content type, it is not stored
+// if it can not be parsed or decoded by the viper.OnConfigChange subscribers. The stored version is
+// returned with the error of a subscriber failing to apply it.
+func (a *ConfigAdapter) Update(name, content, author, comment string) (*ConfigVersion, error) {
+	current, err := a.Current(name)
+	if err != nil {
+		return nil, fmt.Errorf("config '%s': %w", name, err)
+	}
+	v, err := newConfigViper(&ConfigVersion{
+		Name:        name,
+		Version:     current.Version + 1,
+		ContentType: current.ContentType,
+		Content:     content,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = a.Check(name, v)
+	if err != nil {
+		return nil, err
+	}
+	var created *ConfigVersion
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		created, err = a.create(tx, name, current.ContentType, content, author, comment)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	v, err = newConfigViper(created)
+	if err != nil {
+		return created, err
+	}
+	return created, a.Publish(name, v)
+}
+
+// Rollback stores the content of a previous version as the new version of the named config.
+func (a *ConfigAdapter) Rollback(name string, version int, author string) (*ConfigVersion, error) {
+	var v ConfigVersion
+	err := a.db.Where("env = ? AND name = ? AND version = ?", a.env, name, version).First(&v).Error
+	if err != nil {
+		return nil, fmt.Errorf("config '%s' version %d: %w", name, version, err)
+	}
+	return a.Update(name, v.Content, author, fmt.Sprintf("rollback to version %d", version))
+}
+
+func (a *ConfigAdapter) create(tx *gorm.DB, name, ext, content, author, comment string) (*ConfigVersion, error) {
+	var last int
+	err := tx.Model(&ConfigVersion{}).Where("env = ? AND name = ?", a.env, name).
+		Select("COALESCE(MAX(version), 0)").Scan(&last).Error
+	if err != nil {
+		return nil, err
+	}
+	v := &ConfigVersion{
+		Env:         string(a.env),
+		Name:        name,
+		Version:     last + 1,
+		ContentType: ext,
+		Content:     content,
+		Author:      author,
+		Comment:     comment,
+	}
+	err = tx.Create(v).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to store config '%s': %w", name, err)
+	}
+	return v, nil
+}
+
+func newConfigViper(cv *ConfigVersion) (*spf13.Viper, error) {
+	v := spf13.New()
+	v.SetConfigType(cv.ContentType)
+	err := v.ReadConfig(strings.NewReader(cv.Content))
+	if err != nil {
+		return nil, fmt.Errorf("reading config '%s' version %d: %w", cv.Name, cv.Version, err)
+	}
+	viper.BindEnv(cv.Name, v)
+	return v, nil
+}
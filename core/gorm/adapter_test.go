@@ -0,0 +1,111 @@
+package gorm
+
+import (
+	"errors"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/viper"
+	spf13 "github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"path/filepath"
+	"testing"
+)
+
+func newTestConfigAdapter(t *testing.T) *ConfigAdapter {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "configs.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	assert.NoError(t, err)
+	adapter, err := NewConfigAdapter(db, viper.Production)
+	assert.NoError(t, err)
+	return adapter
+}
+
+func TestConfigAdapter(t *testing.T) {
+	adapter := newTestConfigAdapter(t)
+
+	v, err := adapter.NewViper("db-verify", "yaml", `length: 6`)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, v.GetInt("length"))
+
+	// the stored version is used rather than the initial configuration
+	v, err = adapter.NewViper("db-verify", "yaml", `length: 4`)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, v.GetInt("length"))
+
+	var lengths []int
+	cancel := adapter.Subscribe("db-verify", func(v *spf13.Viper) error {
+		lengths = append(lengths, v.GetInt("length"))
+		return nil
+	})
+	defer cancel()
+
+	updated, err := adapter.Update("db-verify", `length: 8`, "admin", "longer codes")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, []int{8}, lengths)
+
+	_, err = adapter.Update("db-verify", `length: [8`, "admin", "")
+	assert.Error(t, err)
+
+	rolled, err := adapter.Rollback("db-verify", 1, "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, rolled.Version)
+	assert.Equal(t, "rollback to version 1", rolled.Comment)
+	assert.Equal(t, []int{8, 6}, lengths)
+
+	versions, err := adapter.Versions("db-verify")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 3)
+	assert.Equal(t, []string{"admin", "admin", SeedAuthor},
+		[]string{versions[0].Author, versions[1].Author, versions[2].Author})
+
+	v, err = adapter.NewViper("db-verify", "yaml", `length: 6`)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, v.GetInt("length"))
+}
+
+func TestConfigAdapter_rejected(t *testing.T) {
+	adapter := newTestConfigAdapter(t)
+	_, err := adapter.NewViper("db-rejected", "yaml", `length: 6`)
+	assert.NoError(t, err)
+
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(adapter))
+	_, err = viper.OnConfigChange("db-rejected", c, func(cfg *struct {
+		Length int `mapstructure:"length" validate:"min=1"`
+	}) error {
+		return nil
+	})
+	assert.NoError(t, err)
+
+	_, err = adapter.Update("db-rejected", `length: 0`, "admin", "")
+	assert.ErrorContains(t, err, "key 'length' fails rule 'min=1'")
+	current, err := adapter.Current("db-rejected")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, current.Version)
+}
+
+func TestConfigAdapter_publishAfterCommit(t *testing.T) {
+	adapter := newTestConfigAdapter(t)
+	_, err := adapter.NewViper("db-commit", "yaml", `length: 6`)
+	assert.NoError(t, err)
+
+	var versions []int
+	cancel := adapter.Subscribe("db-commit", func(v *spf13.Viper) error {
+		current, err := adapter.Current("db-commit")
+		if err != nil {
+			return err
+		}
+		versions = append(versions, current.Version)
+		return errors.New("not applied")
+	})
+	defer cancel()
+
+	// the subscribers see the stored version, which is kept when they fail to apply it
+	updated, err := adapter.Update("db-commit", `length: 8`, "admin", "")
+	assert.EqualError(t, err, "not applied")
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, []int{2}, versions)
+}
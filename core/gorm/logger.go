@@ -0,0 +1,58 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm/logger"
+	"sync/atomic"
+	"time"
+)
+
+// levelLogger is a gorm logger whose level can be changed while the database is in use.
+type levelLogger struct {
+	l atomic.Pointer[logger.Interface]
+}
+
+func newLevelLogger(level int) *levelLogger {
+	l := &levelLogger{}
+	l.setLevel(level)
+	return l
+}
+
+func (l *levelLogger) setLevel(level int) {
+	lg := logger.Default.LogMode(logger.LogLevel(level))
+	l.l.Store(&lg)
+}
+
+func (l *levelLogger) reload(cfg *Config) error {
+	if cfg.LogLevel < int(logger.Silent) || cfg.LogLevel > int(logger.Info) {
+		return fmt.Errorf("invalid log level %d", cfg.LogLevel)
+	}
+	l.setLevel(cfg.LogLevel)
+	return nil
+}
+
+func (l *levelLogger) load() logger.Interface {
+	return *l.l.Load()
+}
+
+// LogMode returns a logger with a fixed level, it is used by sessions such as db.Debug().
+func (l *levelLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return l.load().LogMode(level)
+}
+
+func (l *levelLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	l.load().Info(ctx, msg, data...)
+}
+
+func (l *levelLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	l.load().Warn(ctx, msg, data...)
+}
+
+func (l *levelLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	l.load().Error(ctx, msg, data...)
+}
+
+func (l *levelLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.load().Trace(ctx, begin, fc, err)
+}
@@ -0,0 +1,59 @@
+package gorm
+
+import (
+	"errors"
+	"github.com/aiechoic/admin/pkg/metrics"
+	"gorm.io/gorm"
+	"time"
+)
+
+var (
+	queryDuration = metrics.NewHistogram(
+		"db_query_duration_seconds",
+		"Duration of the database queries by gorm config and operation.",
+		metrics.DefBuckets,
+		"db", "operation",
+	)
+	queryErrors = metrics.NewCounter(
+		"db_query_errors_total",
+		"Number of the failed database queries by gorm config and operation, record not found is not an error.",
+		"db", "operation",
+	)
+)
+
+const startTimeKey = "metrics:start_time"
+
+// registerMetrics registers the callbacks recording the duration of the queries of db, labelled by the
+// name of its config.
+func registerMetrics(db *gorm.DB, name string) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(startTimeKey, time.Now())
+	}
+	after := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			v, ok := tx.InstanceGet(startTimeKey)
+			if !ok {
+				return
+			}
+			queryDuration.Observe(time.Since(v.(time.Time)).Seconds(), name, operation)
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				queryErrors.Inc(name, operation)
+			}
+		}
+	}
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	)
+}
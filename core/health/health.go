@@ -0,0 +1,179 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/aiechoic/admin/core/ioc"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Checker is implemented by container instances which can report their health, such as database and
+// redis clients. Instances can also implement Named to choose the name shown in the report.
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// Named is implemented by checkers to name themselves in the report, the type name is used otherwise.
+type Named interface {
+	HealthName() string
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Adapter returns a Checker for a container instance which can not implement Checker itself, such as a
+// client of a third-party library. It returns false if it does not handle the instance.
+type Adapter func(ins any) (Checker, bool)
+
+var (
+	adapters   []Adapter
+	adaptersMu sync.RWMutex
+)
+
+// RegisterAdapter registers an adapter used to discover checkers among the container instances, it is
+// usually called from the init function of the package providing the instance.
+func RegisterAdapter(adapter Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	adapters = append(adapters, adapter)
+}
+
+func getChecker(ins any) (Checker, bool) {
+	if checker, ok := ins.(Checker); ok {
+		return checker, true
+	}
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	for _, adapter := range adapters {
+		if checker, ok := adapter(ins); ok {
+			return checker, true
+		}
+	}
+	return nil, false
+}
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Result is the result of a single check.
+type Result struct {
+	Name       string `json:"name"`
+	Status     Status `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report is the aggregated result of all checks, its status is down if any check is down.
+type Report struct {
+	Status Status    `json:"status"`
+	Checks []*Result `json:"checks"`
+}
+
+// Health checks the health of the instances of a container.
+type Health struct {
+	container    *ioc.Container
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewHealth(c *ioc.Container, timeout time.Duration) *Health {
+	return &Health{
+		container: c,
+		timeout:   timeout,
+	}
+}
+
+// Check runs the checks of all the container instances implementing Checker, or handled by a registered
+// Adapter, concurrently. Each check is given the configured timeout.
+func (h *Health) Check(ctx context.Context) *Report {
+	type check struct {
+		name    string
+		checker Checker
+	}
+	var checks []check
+	names := map[string]int{}
+	for _, ins := range h.container.GetAllInstances() {
+		checker, ok := getChecker(ins)
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("%T", ins)
+		if named, ok := checker.(Named); ok {
+			name = named.HealthName()
+		}
+		names[name]++
+		if n := names[name]; n > 1 {
+			name = fmt.Sprintf("%s#%d", name, n)
+		}
+		checks = append(checks, check{name: name, checker: checker})
+	}
+
+	report := &Report{Status: StatusUp, Checks: make([]*Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, ck := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = h.run(ctx, ck.name, ck.checker)
+		}()
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (h *Health) run(ctx context.Context, name string, checker Checker) *Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- checker.CheckHealth(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := &Result{Name: name, Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ShutDown marks the application as shutting down, readiness checks fail from now on so the orchestrator
+// stops routing new traffic to it.
+func (h *Health) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready returns the report of Check, or a report with status down without running the checks if the
+// application is shutting down.
+func (h *Health) Ready(ctx context.Context) *Report {
+	if h.shuttingDown.Load() {
+		return &Report{Status: StatusDown, Checks: []*Result{}}
+	}
+	return h.Check(ctx)
+}
@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type database struct {
+	err error
+}
+
+func (d *database) CheckHealth(ctx context.Context) error {
+	return d.err
+}
+
+func (d *database) HealthName() string {
+	return "database"
+}
+
+type slowClient struct{}
+
+func TestHealth_Check(t *testing.T) {
+	RegisterAdapter(func(ins any) (Checker, bool) {
+		if _, ok := ins.(*slowClient); !ok {
+			return nil, false
+		}
+		return CheckerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), true
+	})
+
+	c := ioc.NewTestContainer(t)
+	ioc.NewProvider(func(c *ioc.Container) (*database, error) {
+		return &database{}, nil
+	}).MustGet(c)
+	ioc.NewProvider(func(c *ioc.Container) (*database, error) {
+		return &database{err: errors.New("connection refused")}, nil
+	}).MustGet(c)
+	ioc.NewProvider(func(c *ioc.Container) (*slowClient, error) {
+		return &slowClient{}, nil
+	}).MustGet(c)
+
+	h := NewHealth(c, 50*time.Millisecond)
+	report := h.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Len(t, report.Checks, 3)
+
+	assert.Equal(t, "database", report.Checks[0].Name)
+	assert.Equal(t, StatusUp, report.Checks[0].Status)
+	assert.Equal(t, "database#2", report.Checks[1].Name)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+	assert.Equal(t, "*health.slowClient", report.Checks[2].Name)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[2].Error)
+}
+
+func TestHealth_Ready(t *testing.T) {
+	c := ioc.NewTestContainer(t)
+	h := NewHealth(c, time.Second)
+	assert.Equal(t, StatusUp, h.Ready(context.Background()).Status)
+
+	h.ShutDown()
+	assert.Equal(t, StatusDown, h.Ready(context.Background()).Status)
+	assert.Equal(t, StatusUp, h.Check(context.Background()).Status)
+}
@@ -0,0 +1,63 @@
+// Package healthsvc serves the health and readiness checks of package health over http, it is kept apart
+// so the packages registering checkers do not depend on the http server.
+package healthsvc
+
+import (
+	engin "github.com/aiechoic/admin/core/gin"
+	"github.com/aiechoic/admin/core/gins"
+	"github.com/aiechoic/admin/core/health"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func sendReport(c *gin.Context, report *health.Report) {
+	code := http.StatusOK
+	if report.Status != health.StatusUp {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+// NewService creates a service serving "/healthz" and "/readyz" for the orchestrator. Readiness flips to
+// down as soon as the server starts shutting down gracefully, the listeners are closed after the
+// ShutdownDelay of the server.
+func NewService(h *health.Health, server *engin.Server) *gins.Service {
+	server.RegisterOnShutdown(h.ShutDown)
+	return &gins.Service{
+		Tag:         "Health",
+		Description: "Health and readiness of the application",
+		Security:    gins.NoSecurity,
+		Routes: []gins.Route{
+			{
+				Method:      "GET",
+				Path:        "/healthz",
+				Summary:     "Health check",
+				Description: "Checks the dependencies of the application, responds with 503 if any of them is down",
+				Handler: gins.Handler{
+					Response: gins.Response{
+						Description: "Health report",
+						Json:        health.Report{},
+					},
+					Handle: func(c *gin.Context) {
+						sendReport(c, h.Check(c.Request.Context()))
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/readyz",
+				Summary:     "Readiness check",
+				Description: "Like the health check, but also responds with 503 while the server is shutting down",
+				Handler: gins.Handler{
+					Response: gins.Response{
+						Description: "Readiness report",
+						Json:        health.Report{},
+					},
+					Handle: func(c *gin.Context) {
+						sendReport(c, h.Ready(c.Request.Context()))
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package healthsvc
+
+import (
+	"context"
+	"github.com/aiechoic/admin/core/gins"
+	"github.com/aiechoic/admin/core/health"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/viper"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewService_readyDuringShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(t.TempDir(), viper.Testing)))
+	server, err := gins.GetDefaultAPIServer(c)
+	assert.NoError(t, err)
+	h, err := health.GetDefaultHealth(c)
+	assert.NoError(t, err)
+	server.Register(NewService(h, server.Engin))
+
+	server.Engin.Listen = "127.0.0.1:0"
+	server.Engin.ShutdownDelay = 300 * time.Millisecond
+	assert.NoError(t, server.Engin.Start(context.Background()))
+	url := "http://" + server.Engin.Addr().String() + "/api/v1/readyz"
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	ready := func() (int, error) {
+		rsp, err := client.Get(url)
+		if err != nil {
+			return 0, err
+		}
+		rsp.Body.Close()
+		return rsp.StatusCode, nil
+	}
+	code, err := ready()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	done := make(chan error)
+	go func() { done <- server.Engin.Shutdown(context.Background()) }()
+
+	// the readiness fails while the server still accepts connections, until the delay elapses
+	var unavailable bool
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		code, err := ready()
+		if err != nil {
+			break
+		}
+		if code == http.StatusServiceUnavailable {
+			unavailable = true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, unavailable, "readiness did not fail before the listeners were closed")
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,47 @@
+package health
+
+import (
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/viper"
+	"time"
+)
+
+const DefaultConfig = "health"
+
+var initConfig = `
+# Health check configuration
+
+# maximum time for a single check, such as a database ping
+timeout: "3s"
+`
+
+type Config struct {
+	Timeout time.Duration `mapstructure:"timeout" validate:"min=1ms"`
+}
+
+func init() {
+	viper.RegisterDefault(DefaultConfig, initConfig)
+}
+
+var Providers = ioc.NewProviders(func(name string, args ...any) *ioc.Provider[*Health] {
+	return ioc.NewProvider(func(c *ioc.Container) (*Health, error) {
+		vp, err := viper.GetViper(name, initConfig, c)
+		if err != nil {
+			return nil, err
+		}
+		var cfg Config
+		err = viper.Unmarshal(name, vp, &cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewHealth(c, cfg.Timeout), nil
+	})
+})
+
+func GetHealth(name string, c *ioc.Container) (*Health, error) {
+	return Providers.GetProvider(name).Get(c)
+}
+
+func GetDefaultHealth(c *ioc.Container) (*Health, error) {
+	return GetHealth(DefaultConfig, c)
+}
@@ -2,45 +2,240 @@ package ioc
 
 import (
 	"context"
-	"io"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // injector is an interface for creating new instances.
 type injector interface {
+	fmt.Stringer
 	new(c *Container) (any, error)
+	describe() (name, key string, scope Scope)
+}
+
+// Scope defines the lifetime of the instances created by a provider.
+type Scope int
+
+const (
+	// Singleton instances are created once in the root container and shared with all of its children.
+	Singleton Scope = iota
+	// Scoped instances are created once per container, every child container gets its own instance
+	// which is closed together with the child.
+	Scoped
+	// Transient instances are created on every call to Get and are never stored.
+	Transient
+)
+
+// ErrCycle is reported by Provider.Get when a provider depends on itself, directly or indirectly.
+var ErrCycle = errors.New("dependency cycle detected")
+
+// ErrPanicked is returned to the callers waiting for a build which panicked, the panic itself is
+// propagated to the caller running the build.
+var ErrPanicked = errors.New("provider panicked")
+
+// ResolveError is returned by Provider.Get when an instance can not be created. Path is the chain of
+// providers that were being resolved, from the outermost to the one that failed.
+type ResolveError struct {
+	Path []string
+	Err  error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("resolve %s: %s", strings.Join(e.Path, " -> "), e.Err)
+}
+
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// resolution is a node of the chain of providers currently being constructed.
+type resolution struct {
+	p      injector
+	name   string
+	parent *resolution
+	active atomic.Bool
+	// the stored instance being built, nil for transient builds
+	f *inflight
+}
+
+func (r *resolution) contains(p injector) bool {
+	for ; r != nil; r = r.parent {
+		if r.p == p {
+			return true
+		}
+	}
+	return false
+}
+
+// builds reports whether f is built by the chain of r.
+func (r *resolution) builds(f *inflight) bool {
+	for ; r != nil; r = r.parent {
+		if r.f == f {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *resolution) path() []string {
+	var path []string
+	for ; r != nil; r = r.parent {
+		path = append([]string{r.name}, path...)
+	}
+	return path
 }
 
 // Provider is a provider for a type T.
 type Provider[T any] struct {
-	fn func(c *Container) (T, error)
-	mu sync.Mutex
+	fn    func(c *Container) (T, error)
+	scope Scope
+	name  string
+	key   string
+	group any // the Providers collection the provider belongs to
 }
 
 func (p *Provider[T]) new(c *Container) (any, error) {
 	return p.fn(c)
 }
 
-// NewProvider creates a new provider for the given type T.
+func (p *Provider[T]) describe() (name, key string, scope Scope) {
+	return p.name, p.key, p.scope
+}
+
+// NewProvider creates a new singleton provider for the given type T.
 func NewProvider[T any](fn func(c *Container) (T, error)) *Provider[T] {
-	return &Provider[T]{fn: fn}
+	return &Provider[T]{fn: fn, scope: Singleton, name: typeName[T]()}
+}
+
+// NewScopedProvider creates a new provider for the given type T whose instances live as long as the
+// container they were resolved from, usually a child container created per request.
+func NewScopedProvider[T any](fn func(c *Container) (T, error)) *Provider[T] {
+	return &Provider[T]{fn: fn, scope: Scoped, name: typeName[T]()}
+}
+
+// NewTransientProvider creates a new provider for the given type T which creates a new instance on
+// every call to Get.
+func NewTransientProvider[T any](fn func(c *Container) (T, error)) *Provider[T] {
+	return &Provider[T]{fn: fn, scope: Transient, name: typeName[T]()}
+}
+
+func typeName[T any]() string {
+	return strings.TrimLeft(reflect.TypeFor[T]().String(), "*")
+}
+
+// WithName sets a human-readable name used in diagnostics, the type name of T is used by default.
+func (p *Provider[T]) WithName(name string) *Provider[T] {
+	p.name = name
+	return p
+}
+
+// Name returns the human-readable name of the provider.
+func (p *Provider[T]) Name() string {
+	return p.name
+}
+
+// Key returns the name of the provider in its Providers collection, usually the config name, or an empty
+// string if the provider is not part of a collection.
+func (p *Provider[T]) Key() string {
+	return p.key
+}
+
+// String returns the name of the provider followed by its name in the Providers collection, if any.
+// For example "redis.Client(redis)".
+func (p *Provider[T]) String() string {
+	if p.key == "" {
+		return p.name
+	}
+	return p.name + "(" + p.key + ")"
+}
+
+// Scope returns the scope of the provider.
+func (p *Provider[T]) Scope() Scope {
+	return p.scope
+}
+
+// build calls the provider function with a container that records p in its resolution chain, so that
+// nested calls to Get can detect cycles and report the full chain on failure.
+func (p *Provider[T]) build(c *Container, caller *resolution, f *inflight) (T, error) {
+	node := &resolution{p: p, name: p.String(), parent: caller, f: f}
+	if caller == nil {
+		// the dependencies resolved during the build are recorded by Get
+		c.record(nil, p)
+	}
+	node.active.Store(true)
+	defer node.active.Store(false)
+	t, err := p.fn(c.resolving(node))
+	if err != nil {
+		var re *ResolveError
+		if !errors.As(err, &re) {
+			err = &ResolveError{Path: node.path(), Err: err}
+		}
+		return t, err
+	}
+	return t, nil
 }
 
 // Get returns an instance of T. If the instance is already created, it returns the existing instance.
-// If the instance is not created, it creates a new instance, stores it, and returns it.
+// If the instance is not created, it creates a new instance, stores it, and returns it. Instances
+// substituted with Container.Override are returned as is.
+//
+// Singleton instances are looked up in c and its parents and created in the root container, scoped
+// instances are looked up and created in c only, transient instances are always created.
+//
+// If p is already being constructed further up the chain, or by another goroutine waiting for the chain,
+// Get returns a ResolveError wrapping ErrCycle instead of deadlocking.
 func (p *Provider[T]) Get(c *Container) (t T, err error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	v, ok := c.get(p)
+	caller := c.caller()
+	if caller.contains(p) {
+		path := append(caller.path(), p.String())
+		return t, &ResolveError{Path: path, Err: ErrCycle}
+	}
+	if caller != nil {
+		// only recorded while the caller is being built, the cached lookups of the handlers stay lock-free
+		c.record(caller, p)
+	}
+	if v, ok := c.override(p, p.group, p.key); ok {
+		return v.(T), nil
+	}
+	if p.scope == Transient {
+		return p.build(c, caller, nil)
+	}
+	v, ok := p.lookup(c)
 	if ok {
 		return v.(T), nil
 	}
-	t, err = p.fn(c)
+	owner := c
+	if p.scope == Singleton {
+		owner = c.root()
+	}
+	v, err = owner.once(p, caller, func(f *inflight) (any, error) {
+		return p.build(owner, caller, f)
+	})
+	if errors.Is(err, errWaitCycle) {
+		return t, &ResolveError{Path: append(caller.path(), p.String()), Err: ErrCycle}
+	}
 	if err != nil {
 		return
 	}
-	c.set(p, t)
-	return t, nil
+	return v.(T), nil
+}
+
+// lookup finds an existing instance of the provider, singletons fall back to the parent containers.
+func (p *Provider[T]) lookup(c *Container) (any, bool) {
+	if p.scope != Singleton {
+		return c.get(p)
+	}
+	for ; c != nil; c = c.parent {
+		if v, ok := c.get(p); ok {
+			return v, true
+		}
+	}
+	return nil, false
 }
 
 // MustGet is like Get, but it panics if an error occurs.
@@ -54,7 +249,12 @@ func (p *Provider[T]) MustGet(c *Container) T {
 
 // GetNew creates a new instance of T and returns it. It does not store the instance.
 func (p *Provider[T]) GetNew(c *Container) (T, error) {
-	t, err := p.fn(c)
+	caller := c.caller()
+	if caller.contains(p) {
+		var zero T
+		return zero, &ResolveError{Path: append(caller.path(), p.String()), Err: ErrCycle}
+	}
+	t, err := p.build(c, caller, nil)
 	if err != nil {
 		var zero T
 		return zero, err
@@ -73,74 +273,128 @@ func (p *Provider[T]) MustGetNew(c *Container) T {
 
 // IsSet returns true if the instance of T is already created.
 func (p *Provider[T]) IsSet(c *Container) bool {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	_, ok := c.get(p)
+	_, ok := p.lookup(c)
 	return ok
 }
 
 // Set stores the instance of T.
 func (p *Provider[T]) Set(c *Container, v T) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
 	c.set(p, v)
 }
 
-type Generator[T any, C comparable] func(name string, args ...C) *Provider[T]
+// Container is a container for managing instances.
+//
+// A Container is a lightweight handle, the instances are kept in a state shared by all handles of the
+// same container. Providers receive a handle which also carries the chain of providers being resolved.
+type Container struct {
+	*state
+	parent *Container
+	path   *resolution
+}
 
-// Providers is a collection of providers. It is used to manage named providers.
-type Providers[T any, C comparable] struct {
-	ps   map[string]*Provider[T]
-	args map[string][]C
-	fn   Generator[T, C]
-	mu   sync.Mutex
+type state struct {
+	instances map[injector]any
+	order     []injector // creation order of the instances
+	cancel    context.CancelFunc
+	// time given to the components to stop, see Run
+	stopTimeout time.Duration
+	// dependency graph, only recorded in the root container
+	deps *depGraph
+	// substituted instances, see Override
+	overrides map[any]any
+	// providers built by Warmup
+	required []Dependency
+	// instances being built, see once
+	building map[injector]*inflight
+	mu       sync.Mutex
 }
 
-// NewProviders creates a new Providers instance.
-// The fn parameter is a function that creates a new provider for the given name.
-func NewProviders[T any, C comparable](fn Generator[T, C]) *Providers[T, C] {
-	return &Providers[T, C]{
-		ps:   map[string]*Provider[T]{},
-		args: map[string][]C{},
-		fn:   fn,
-	}
+// inflight is an instance being built, the other callers wait for it instead of building it again.
+type inflight struct {
+	done chan struct{}
+	v    any
+	err  error
+	// the build the builder of this one is waiting for, guarded by waitsMu
+	waiting *inflight
 }
 
-// GetProvider returns a provider with the given name.
-func (ps *Providers[T, C]) GetProvider(name string, args ...C) *Provider[T] {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-	p, ok := ps.ps[name]
-	if !ok {
-		p = ps.fn(name, args...)
-		ps.ps[name] = p
-		ps.args[name] = args
-	} else {
-		_args := ps.args[name]
-		if len(args) != len(_args) {
-			panic("args changed")
+// waitsMu guards the waits-for relation between the builds, see wait.
+var waitsMu sync.Mutex
+
+// errWaitCycle is returned by wait when the build waited for depends on the builds of the caller.
+var errWaitCycle = errors.New("wait cycle")
+
+// wait waits for f to be built. The builds of the caller chain are marked as waiting for f, so a caller
+// of another goroutine waiting for them can tell that it would wait for itself. For example when two
+// goroutines resolve A -> B and B -> A at the same time, one of them gets errWaitCycle instead of both
+// waiting forever.
+func wait(caller *resolution, f *inflight) error {
+	waitsMu.Lock()
+	for w := f; w != nil; w = w.waiting {
+		if caller.builds(w) {
+			waitsMu.Unlock()
+			return errWaitCycle
 		}
-		for i, dep := range args {
-			if dep != _args[i] {
-				panic("args changed")
-			}
+	}
+	for r := caller; r != nil; r = r.parent {
+		if r.f != nil {
+			r.f.waiting = f
 		}
 	}
-	return p
-}
+	waitsMu.Unlock()
 
-// Container is a container for managing instances.
-type Container struct {
-	instances map[injector]any
-	cancel    context.CancelFunc
-	mu        sync.Mutex
+	<-f.done
+
+	waitsMu.Lock()
+	for r := caller; r != nil; r = r.parent {
+		if r.f != nil {
+			r.f.waiting = nil
+		}
+	}
+	waitsMu.Unlock()
+	return nil
 }
 
 // NewContainer creates a new container.
 func NewContainer() *Container {
 	return &Container{
-		instances: map[injector]any{},
+		state: &state{instances: map[injector]any{}},
+	}
+}
+
+// Child creates a child container. Singleton instances are shared with the parent, scoped instances
+// are created in the child and closed when the child is closed.
+func (c *Container) Child() *Container {
+	return &Container{
+		state:  &state{instances: map[injector]any{}},
+		parent: c,
+	}
+}
+
+// Parent returns the parent container, or nil if c is a root container.
+func (c *Container) Parent() *Container {
+	return c.parent
+}
+
+func (c *Container) root() *Container {
+	for c.parent != nil {
+		c = c.parent
 	}
+	return c
+}
+
+// resolving returns a handle of c which records node as the provider being constructed.
+func (c *Container) resolving(node *resolution) *Container {
+	return &Container{state: c.state, parent: c.parent, path: node}
+}
+
+// caller returns the provider currently being constructed through c. A handle kept by an instance after
+// its construction finished is no longer part of a resolution chain.
+func (c *Container) caller() *resolution {
+	if c.path == nil || !c.path.active.Load() {
+		return nil
+	}
+	return c.path
 }
 
 func (c *Container) get(p injector) (any, bool) {
@@ -150,20 +404,69 @@ func (c *Container) get(p injector) (any, bool) {
 	return i, ok
 }
 
+// once builds the instance of p stored in c, unless it is already stored or being built by another caller,
+// in which case it waits for that build. Only the callers of the same provider and container wait for
+// each other, so a slow build does not block the other providers or containers.
+func (c *Container) once(p injector, caller *resolution, build func(f *inflight) (any, error)) (any, error) {
+	c.mu.Lock()
+	if v, ok := c.instances[p]; ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	if f, ok := c.building[p]; ok {
+		c.mu.Unlock()
+		if err := wait(caller, f); err != nil {
+			return nil, err
+		}
+		return f.v, f.err
+	}
+	if c.building == nil {
+		c.building = map[injector]*inflight{}
+	}
+	f := &inflight{done: make(chan struct{})}
+	c.building[p] = f
+	c.mu.Unlock()
+
+	// the build is released even if the provider panics, so that the next callers build it again
+	panicked := true
+	defer func() {
+		if panicked {
+			f.v, f.err = nil, &ResolveError{Path: []string{p.String()}, Err: ErrPanicked}
+		}
+		c.mu.Lock()
+		if f.err == nil {
+			if _, ok := c.instances[p]; !ok {
+				c.order = append(c.order, p)
+			}
+			c.instances[p] = f.v
+		}
+		delete(c.building, p)
+		c.mu.Unlock()
+		close(f.done)
+	}()
+	f.v, f.err = build(f)
+	panicked = false
+	return f.v, f.err
+}
+
 func (c *Container) set(p injector, ins any) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if _, ok := c.instances[p]; !ok {
+		c.order = append(c.order, p)
+	}
 	c.instances[p] = ins
 }
 
-// GetAllInstances returns all instances in the container.
+// GetAllInstances returns all instances stored in the container in creation order, instances of the
+// parent containers are not included.
 func (c *Container) GetAllInstances() []any {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	var instances []any
-	for _, ins := range c.instances {
-		instances = append(instances, ins)
+	for _, p := range c.order {
+		instances = append(instances, c.instances[p])
 	}
 	return instances
 }
@@ -178,22 +481,3 @@ func (errs Errors) Error() string {
 	}
 	return str
 }
-
-// Close closes all instances that implement the io.Closer interface.
-func (c *Container) Close() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	var errs Errors
-	for _, ins := range c.instances {
-		if closer, ok := ins.(io.Closer); ok {
-			err := closer.Close()
-			if err != nil {
-				errs = append(errs, err)
-			}
-		}
-	}
-	if len(errs) > 0 {
-		return errs
-	}
-	return nil
-}
@@ -0,0 +1,182 @@
+package ioc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type cycleA struct{}
+
+type cycleB struct{}
+
+var cycleAProvider *Provider[*cycleA]
+
+var cycleBProvider = NewProvider(func(c *Container) (*cycleB, error) {
+	_, err := cycleAProvider.Get(c)
+	return &cycleB{}, err
+})
+
+func init() {
+	cycleAProvider = NewProvider(func(c *Container) (*cycleA, error) {
+		_, err := cycleBProvider.Get(c)
+		return &cycleA{}, err
+	})
+}
+
+func TestProvider_GetCycle(t *testing.T) {
+	c := NewContainer()
+
+	_, err := cycleAProvider.Get(c)
+	assert.ErrorIs(t, err, ErrCycle)
+
+	var re *ResolveError
+	assert.True(t, errors.As(err, &re))
+	assert.Equal(t, []string{"ioc.cycleA", "ioc.cycleB", "ioc.cycleA"}, re.Path)
+	assert.Equal(t, "resolve ioc.cycleA -> ioc.cycleB -> ioc.cycleA: dependency cycle detected", err.Error())
+	assert.False(t, cycleAProvider.IsSet(c))
+}
+
+func TestProvider_GetErrorPath(t *testing.T) {
+	errConfig := errors.New("bad config")
+	config := NewProviders(func(name string, args ...any) *Provider[string] {
+		return NewProvider(func(c *Container) (string, error) {
+			return "", errConfig
+		})
+	})
+	client := NewProvider(func(c *Container) (int, error) {
+		_, err := config.GetProvider("redis").Get(c)
+		return 0, err
+	}).WithName("Client")
+	server := NewProvider(func(c *Container) (bool, error) {
+		_, err := client.Get(c)
+		return false, err
+	})
+
+	_, err := server.Get(NewContainer())
+	assert.ErrorIs(t, err, errConfig)
+	assert.Equal(t, "resolve bool -> Client -> string(redis): bad config", err.Error())
+
+	assert.PanicsWithError(t, err.Error(), func() {
+		server.MustGet(NewContainer())
+	})
+}
+
+func TestProvider_GetAfterConstruction(t *testing.T) {
+	var kept *Container
+	lazy := NewProvider(func(c *Container) (int, error) {
+		kept = c
+		return 1, nil
+	})
+	c := NewContainer()
+	lazy.MustGet(c)
+
+	// a handle kept by an instance is no longer part of the resolution chain
+	v, err := lazy.Get(kept)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestProvider_GetConcurrentContainers(t *testing.T) {
+	release := make(chan struct{})
+	var builds atomic.Int32
+	slow := NewProvider(func(c *Container) (int, error) {
+		if builds.Add(1) == 1 {
+			<-release
+		}
+		return 1, nil
+	})
+
+	blocked := NewContainer()
+	done := make(chan int)
+	go func() { done <- slow.MustGet(blocked) }()
+	for builds.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the build in another container does not wait for the slow one
+	assert.Equal(t, 1, slow.MustGet(NewContainer()))
+
+	// the callers of the same container wait for the build in progress
+	go func() { done <- slow.MustGet(blocked) }()
+	close(release)
+	assert.Equal(t, 1, <-done)
+	assert.Equal(t, 1, <-done)
+	assert.Equal(t, int32(2), builds.Load())
+}
+
+func TestProvider_GetPanic(t *testing.T) {
+	release := make(chan struct{})
+	var builds atomic.Int32
+	p := NewProvider(func(c *Container) (int, error) {
+		if builds.Add(1) == 1 {
+			<-release
+			panic("connection refused")
+		}
+		return 1, nil
+	})
+
+	c := NewContainer()
+	panicked := make(chan any)
+	go func() {
+		defer func() { panicked <- recover() }()
+		p.Get(c)
+	}()
+	for builds.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the caller waiting for the build gets an error once the provider panics
+	waited := make(chan error)
+	go func() {
+		_, err := p.Get(c)
+		waited <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Equal(t, "connection refused", <-panicked)
+	assert.ErrorIs(t, <-waited, ErrPanicked)
+
+	// the build is released, the next call builds it again
+	v, err := p.Get(c)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestProvider_GetConcurrentCycle(t *testing.T) {
+	aStarted, bStarted := make(chan struct{}), make(chan struct{})
+	var a *Provider[*cycleA]
+	b := NewProvider(func(c *Container) (*cycleB, error) {
+		close(bStarted)
+		<-aStarted
+		_, err := a.Get(c)
+		return &cycleB{}, err
+	})
+	a = NewProvider(func(c *Container) (*cycleA, error) {
+		close(aStarted)
+		<-bStarted
+		_, err := b.Get(c)
+		return &cycleA{}, err
+	})
+
+	// both goroutines start building before either resolves the other
+	c := NewContainer()
+	errs := make(chan error, 2)
+	go func() {
+		_, err := a.Get(c)
+		errs <- err
+	}()
+	go func() {
+		_, err := b.Get(c)
+		errs <- err
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			assert.ErrorIs(t, err, ErrCycle)
+		case <-time.After(time.Second):
+			t.Fatal("deadlock resolving a concurrent cycle")
+		}
+	}
+}
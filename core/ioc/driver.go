@@ -0,0 +1,113 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DriverFactory creates the implementation of T registered as a driver, name is the name of the config
+// the driver reads its settings from. The drivers receiving their settings otherwise are registered with
+// RegisterConfigDriver.
+type DriverFactory[T any] func(c *Container, name string) (T, error)
+
+var (
+	drivers   = map[reflect.Type]map[string]any{}
+	driversMu sync.RWMutex
+)
+
+// DriverError is returned by NewDriver when no driver is registered with the requested name.
+type DriverError struct {
+	Type      string
+	Driver    string
+	Available []string
+}
+
+func (e *DriverError) Error() string {
+	return fmt.Sprintf("unknown driver %q for %s, available drivers: %s",
+		e.Driver, e.Type, strings.Join(e.Available, ", "))
+}
+
+// RegisterDriver registers a factory creating an implementation of T under the driver name, it is usually
+// called from the init function of the package providing the implementation. It panics if the driver is
+// already registered for T.
+//
+//	ioc.RegisterDriver[redis.Store]("memory", newMemoryStore)
+func RegisterDriver[T any](driver string, factory DriverFactory[T]) {
+	registerDriver(reflect.TypeFor[T](), typeName[T](), driver, factory)
+}
+
+// Drivers returns the sorted names of the drivers registered for T.
+func Drivers[T any]() []string {
+	return driverNames(reflect.TypeFor[T]())
+}
+
+// ConfigDriverFactory creates the implementation of T registered as a driver from the config C, which the
+// provider selecting the driver has already decoded, so the driver does not read the config again.
+type ConfigDriverFactory[T, C any] func(c *Container, cfg C) (T, error)
+
+// RegisterConfigDriver registers a factory creating an implementation of T from the decoded config C under
+// the driver name, see RegisterDriver. The drivers of T taking different config types are independent.
+//
+//	ioc.RegisterConfigDriver("sqlite", func(c *ioc.Container, cfg *gorm.Config) (gorm.Dialector, error) {
+//		return sqlite.Open(cfg.DSN), nil
+//	})
+func RegisterConfigDriver[T, C any](driver string, factory ConfigDriverFactory[T, C]) {
+	registerDriver(reflect.TypeFor[ConfigDriverFactory[T, C]](), typeName[T](), driver, factory)
+}
+
+// ConfigDrivers returns the sorted names of the drivers registered for T taking the config C.
+func ConfigDrivers[T, C any]() []string {
+	return driverNames(reflect.TypeFor[ConfigDriverFactory[T, C]]())
+}
+
+// NewConfigDriver creates the implementation of T registered under the driver name from cfg, it returns a
+// *DriverError listing the available drivers if there is no such driver.
+func NewConfigDriver[T, C any](driver string, c *Container, cfg C) (T, error) {
+	driversMu.RLock()
+	factory, ok := drivers[reflect.TypeFor[ConfigDriverFactory[T, C]]()][driver]
+	driversMu.RUnlock()
+	if !ok {
+		var zero T
+		return zero, &DriverError{Type: typeName[T](), Driver: driver, Available: ConfigDrivers[T, C]()}
+	}
+	return factory.(ConfigDriverFactory[T, C])(c, cfg)
+}
+
+func registerDriver(key reflect.Type, typ, driver string, factory any) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if drivers[key] == nil {
+		drivers[key] = map[string]any{}
+	}
+	if _, ok := drivers[key][driver]; ok {
+		panic(fmt.Sprintf("driver %q already registered for %s", driver, typ))
+	}
+	drivers[key][driver] = factory
+}
+
+func driverNames(key reflect.Type) []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	var names []string
+	for name := range drivers[key] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewDriver creates the implementation of T registered under the driver name, it returns a *DriverError
+// listing the available drivers if there is no such driver.
+func NewDriver[T any](driver string, c *Container, name string) (T, error) {
+	driversMu.RLock()
+	factory, ok := drivers[reflect.TypeFor[T]()][driver]
+	driversMu.RUnlock()
+	if !ok {
+		var zero T
+		return zero, &DriverError{Type: typeName[T](), Driver: driver, Available: Drivers[T]()}
+	}
+	return factory.(DriverFactory[T])(c, name)
+}
@@ -0,0 +1,59 @@
+package ioc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testStore interface {
+	Name() string
+}
+
+type testStoreImpl string
+
+func (s testStoreImpl) Name() string { return string(s) }
+
+type testStoreConfig struct {
+	Prefix string
+}
+
+// the drivers are registered once, the registry is global and panics on duplicates, so the tests can be
+// run with -count
+func init() {
+	RegisterDriver("test-memory", func(c *Container, name string) (testStore, error) {
+		return testStoreImpl("memory:" + name), nil
+	})
+	RegisterDriver("test-redis", func(c *Container, name string) (testStore, error) {
+		return testStoreImpl("redis:" + name), nil
+	})
+	RegisterConfigDriver("test-memory", func(c *Container, cfg *testStoreConfig) (testStore, error) {
+		return testStoreImpl("memory:" + cfg.Prefix), nil
+	})
+}
+
+func TestNewDriver(t *testing.T) {
+	assert.Equal(t, []string{"test-memory", "test-redis"}, Drivers[testStore]())
+	assert.Panics(t, func() {
+		RegisterDriver("test-redis", func(c *Container, name string) (testStore, error) { return nil, nil })
+	})
+
+	s, err := NewDriver[testStore]("test-memory", NewContainer(), "cache")
+	assert.NoError(t, err)
+	assert.Equal(t, "memory:cache", s.Name())
+
+	_, err = NewDriver[testStore]("mongo", NewContainer(), "cache")
+	var de *DriverError
+	assert.ErrorAs(t, err, &de)
+	assert.Equal(t, `unknown driver "mongo" for ioc.testStore, available drivers: test-memory, test-redis`, err.Error())
+}
+
+func TestNewConfigDriver(t *testing.T) {
+	assert.Equal(t, []string{"test-memory"}, ConfigDrivers[testStore, *testStoreConfig]())
+
+	s, err := NewConfigDriver[testStore]("test-memory", NewContainer(), &testStoreConfig{Prefix: "cache:"})
+	assert.NoError(t, err)
+	assert.Equal(t, "memory:cache:", s.Name())
+
+	_, err = NewConfigDriver[testStore]("test-redis", NewContainer(), &testStoreConfig{})
+	assert.EqualError(t, err, `unknown driver "test-redis" for ioc.testStore, available drivers: test-memory`)
+}
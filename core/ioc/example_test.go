@@ -35,7 +35,7 @@ func ExampleProvider_Get() {
 	defer func() {
 		err := c.Close()
 		if err != nil {
-			fmt.Println(err) // Output: ServiceB.Close error
+			fmt.Println(err)
 		}
 	}()
 
@@ -54,7 +54,7 @@ func ExampleProvider_Get() {
 	// Output:
 	// serviceA.ServiceB == nil: false
 	// serviceA.ServiceB == serviceB: true
-	// ServiceB.Close error
+	// close ioc.ServiceB: ServiceB.Close error
 }
 
 type Client struct {
@@ -83,3 +83,56 @@ func ExampleNewProviders() {
 	// client2.Name='client2'
 	// client1 == clientOld: true
 }
+
+type RequestID struct {
+	ID int
+}
+
+var requestIDs int
+
+var requestIDProvider = NewScopedProvider(func(c *Container) (*RequestID, error) {
+	requestIDs++
+	return &RequestID{ID: requestIDs}, nil
+})
+
+func ExampleContainer_Child() {
+	c := NewContainer()
+
+	serviceB := ServiceBProvider.MustGet(c)
+
+	scope1 := c.Child()
+	scope2 := c.Child()
+
+	id1 := requestIDProvider.MustGet(scope1)
+	id2 := requestIDProvider.MustGet(scope2)
+
+	fmt.Printf("scope1 request id: %d\n", id1.ID)
+	fmt.Printf("scope2 request id: %d\n", id2.ID)
+	fmt.Printf("scope1 reuses its request id: %v\n", requestIDProvider.MustGet(scope1) == id1)
+	fmt.Printf("scopes share singletons: %v\n", ServiceBProvider.MustGet(scope1) == serviceB)
+
+	// Output:
+	// scope1 request id: 1
+	// scope2 request id: 2
+	// scope1 reuses its request id: true
+	// scopes share singletons: true
+}
+
+func ExampleContainer_Graph() {
+	c := NewContainer()
+
+	ServiceAProvider.MustGet(c)
+	clientProviders.GetProvider("client1").MustGet(c)
+
+	fmt.Print(c.Graph().DOT())
+
+	// Output:
+	// digraph ioc {
+	// 	rankdir=LR;
+	// 	node [shape=box];
+	// 	n0 [label="ioc.ServiceA"];
+	// 	n1 [label="ioc.ServiceB"];
+	// 	n2 [label="ioc.Client\nconfig: client1"];
+	// 	n0 -> n1;
+	// }
+}
@@ -0,0 +1,149 @@
+package ioc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// String returns the name of the scope.
+func (s Scope) String() string {
+	switch s {
+	case Singleton:
+		return "singleton"
+	case Scoped:
+		return "scoped"
+	case Transient:
+		return "transient"
+	}
+	return fmt.Sprintf("Scope(%d)", int(s))
+}
+
+// Node is a provider in the dependency graph.
+type Node struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`             // name of the provider, the type name by default
+	Config string `json:"config,omitempty"` // name of the provider in its Providers collection, the config name
+	Scope  string `json:"scope"`
+}
+
+// Edge means the From provider resolved the To provider while it was being constructed.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the dependency graph of the providers resolved in a container.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// depGraph records the providers and their dependencies as they are resolved.
+type depGraph struct {
+	nodes []injector
+	ids   map[injector]string
+	edges map[[2]injector]bool
+	order [][2]injector
+	mu    sync.Mutex
+}
+
+func (g *depGraph) node(p injector) string {
+	id, ok := g.ids[p]
+	if !ok {
+		id = fmt.Sprintf("n%d", len(g.nodes))
+		g.ids[p] = id
+		g.nodes = append(g.nodes, p)
+	}
+	return id
+}
+
+func (g *depGraph) add(caller *resolution, p injector) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.node(p)
+	if caller == nil {
+		return
+	}
+	g.node(caller.p)
+	edge := [2]injector{caller.p, p}
+	if !g.edges[edge] {
+		g.edges[edge] = true
+		g.order = append(g.order, edge)
+	}
+}
+
+// record adds p to the dependency graph of the root container, with an edge from the provider that is
+// resolving it, if any. It is only called while an instance is being built, the lookups of the instances
+// already built do not take the locks of the graph.
+func (c *Container) record(caller *resolution, p injector) {
+	root := c.root()
+	root.mu.Lock()
+	if root.deps == nil {
+		root.deps = &depGraph{ids: map[injector]string{}, edges: map[[2]injector]bool{}}
+	}
+	deps := root.deps
+	root.mu.Unlock()
+	deps.add(caller, p)
+}
+
+// Graph returns the dependency graph of the providers resolved so far, the edges are recorded whenever a
+// provider resolves another provider during its construction. Child containers share the graph of the
+// root container.
+func (c *Container) Graph() *Graph {
+	root := c.root()
+	root.mu.Lock()
+	deps := root.deps
+	root.mu.Unlock()
+
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	if deps == nil {
+		return g
+	}
+	deps.mu.Lock()
+	defer deps.mu.Unlock()
+	for _, p := range deps.nodes {
+		name, key, scope := p.describe()
+		g.Nodes = append(g.Nodes, Node{
+			ID:     deps.ids[p],
+			Type:   name,
+			Config: key,
+			Scope:  scope.String(),
+		})
+	}
+	for _, e := range deps.order {
+		g.Edges = append(g.Edges, Edge{From: deps.ids[e[0]], To: deps.ids[e[1]]})
+	}
+	return g
+}
+
+// DOT renders the graph in the Graphviz DOT language, for example:
+//
+//	go run . | dot -Tsvg > graph.svg
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph ioc {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		label := n.Type
+		if n.Config != "" {
+			label += "\\nconfig: " + n.Config
+		}
+		if n.Scope != Singleton.String() {
+			label += "\\n(" + n.Scope + ")"
+		}
+		fmt.Fprintf(&b, "\t%s [label=\"%s\"];\n", n.ID, strings.ReplaceAll(label, `"`, `\"`))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", e.From, e.To)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// JSON renders the graph as indented JSON.
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
@@ -0,0 +1,183 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Starter is implemented by components that start background work, Start should return once the
+// component is started.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by components that need to be stopped when the container stops running.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Runner is implemented by components that block while running, such as servers and queue consumers.
+// Run should return when ctx is cancelled.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// DefaultStopTimeout is the default time given to the components to stop, see Container.SetStopTimeout.
+const DefaultStopTimeout = 10 * time.Second
+
+// ErrRunning is returned by Container.Run when the container is already running.
+var ErrRunning = errors.New("container is already running")
+
+// SetStopTimeout sets the time given to the components to stop after Run is cancelled.
+func (c *Container) SetStopTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopTimeout = d
+}
+
+// Run runs the components stored in the container until ctx is cancelled, Cancel is called, a SIGINT or
+// SIGTERM is received, or a component fails.
+//
+// Components implementing Runner are run and components implementing Starter are started, all of them
+// concurrently. An error returned by Start or Run cancels all other components. After cancellation, Run
+// waits for the runners to return and stops the components implementing Stopper in reverse creation
+// order, so a component is stopped before the components it depends on. Only the components created
+// before Run is called are managed, use Warmup or Get to create them first.
+//
+// The returned error is an Errors recording the failed components as *InstanceError, cancellation is
+// not reported as an error.
+func (c *Container) Run(ctx context.Context) error {
+	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	ctx = context.WithValue(ctx, runningKey{}, c.state)
+
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.mu.Unlock()
+		return ErrRunning
+	}
+	c.cancel = cancel
+	stopTimeout := c.stopTimeout
+	if stopTimeout <= 0 {
+		stopTimeout = DefaultStopTimeout
+	}
+	order := make([]injector, len(c.order))
+	copy(order, c.order)
+	instances := make([]any, len(order))
+	for i, p := range order {
+		instances[i] = c.instances[p]
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.cancel = nil
+		c.mu.Unlock()
+	}()
+
+	var (
+		mu      sync.Mutex
+		errs    Errors
+		wg      sync.WaitGroup
+		started = make([]bool, len(order))
+	)
+	fail := func(op string, p injector, err error) {
+		mu.Lock()
+		errs = append(errs, &InstanceError{Op: op, Provider: p.String(), Err: err})
+		mu.Unlock()
+		cancel()
+	}
+
+	for i, ins := range instances {
+		p := order[i]
+		switch v := ins.(type) {
+		case Runner:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := v.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					fail("run", p, err)
+				}
+			}()
+		case Starter:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := v.Start(ctx); err != nil {
+					fail("start", p, err)
+					return
+				}
+				mu.Lock()
+				started[i] = true
+				mu.Unlock()
+			}()
+		}
+	}
+
+	<-ctx.Done()
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer stopCancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-stopCtx.Done():
+		mu.Lock()
+		errs = append(errs, fmt.Errorf("waiting for components to return: %w", stopCtx.Err()))
+		mu.Unlock()
+	}
+
+	for i := len(instances) - 1; i >= 0; i-- {
+		stopper, ok := instances[i].(Stopper)
+		if !ok {
+			continue
+		}
+		if _, ok = instances[i].(Starter); ok {
+			mu.Lock()
+			ok = started[i]
+			mu.Unlock()
+			if !ok {
+				continue
+			}
+		}
+		if err := closeWithContext(stopCtx, stopper.Stop); err != nil {
+			fail("stop", order[i], err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type runningKey struct{}
+
+// Running reports whether ctx is the context passed by Run of c to the components. A Runner which runs the
+// container itself, such as an api server, returns at once when it is run by the container.
+func (c *Container) Running(ctx context.Context) bool {
+	// the handles given to the providers share the state of their container
+	running, _ := ctx.Value(runningKey{}).(*state)
+	return running == c.state
+}
+
+// Cancel stops a running Run call, it does nothing if the container is not running.
+func (c *Container) Cancel() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
@@ -0,0 +1,125 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lifecycleLog struct {
+	events []string
+	mu     sync.Mutex
+}
+
+func (l *lifecycleLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+type worker struct {
+	name string
+	log  *lifecycleLog
+}
+
+func (w *worker) Start(ctx context.Context) error {
+	w.log.add("start " + w.name)
+	return nil
+}
+
+func (w *worker) Stop(ctx context.Context) error {
+	w.log.add("stop " + w.name)
+	return nil
+}
+
+type consumer struct {
+	log *lifecycleLog
+	err error
+}
+
+func (r *consumer) Run(ctx context.Context) error {
+	if r.err != nil {
+		return r.err
+	}
+	<-ctx.Done()
+	r.log.add("run returned")
+	return ctx.Err()
+}
+
+func TestContainer_Run(t *testing.T) {
+	events := &lifecycleLog{}
+	cacheWarmer := NewProvider(func(c *Container) (*worker, error) {
+		return &worker{name: "cache", log: events}, nil
+	})
+	scheduler := NewProvider(func(c *Container) (*worker, error) {
+		cacheWarmer.MustGet(c)
+		return &worker{name: "scheduler", log: events}, nil
+	})
+	queue := NewProvider(func(c *Container) (*consumer, error) {
+		return &consumer{log: events}, nil
+	})
+
+	c := NewContainer()
+	scheduler.MustGet(c)
+	queue.MustGet(c)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		c.Cancel()
+	}()
+	err := c.Run(context.Background())
+	assert.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"start cache", "start scheduler"}, events.events[:2])
+	assert.Equal(t, []string{"run returned", "stop scheduler", "stop cache"}, events.events[2:])
+}
+
+func TestContainer_RunFailure(t *testing.T) {
+	events := &lifecycleLog{}
+	errBroken := errors.New("broken queue")
+	cacheWarmer := NewProvider(func(c *Container) (*worker, error) {
+		return &worker{name: "cache", log: events}, nil
+	})
+	queue := NewProvider(func(c *Container) (*consumer, error) {
+		return &consumer{log: events, err: errBroken}, nil
+	})
+
+	c := NewContainer()
+	cacheWarmer.MustGet(c)
+	queue.MustGet(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := c.Run(ctx)
+	assert.ErrorIs(t, err.(Errors)[0], errBroken)
+	assert.Equal(t, "run ioc.consumer: broken queue", err.(Errors)[0].Error())
+	assert.Equal(t, "stop cache", events.events[len(events.events)-1])
+}
+
+type runnerFunc func(ctx context.Context) error
+
+func (f runnerFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestContainer_Running(t *testing.T) {
+	c := NewContainer()
+	other := NewContainer()
+	assert.False(t, c.Running(context.Background()))
+
+	running := make(chan bool, 2)
+	NewProvider(func(c *Container) (runnerFunc, error) {
+		return func(ctx context.Context) error {
+			running <- c.Running(ctx)
+			running <- other.Running(ctx)
+			c.Cancel()
+			return nil
+		}, nil
+	}).MustGet(c)
+	assert.NoError(t, c.Run(context.Background()))
+	assert.True(t, <-running)
+	assert.False(t, <-running)
+}
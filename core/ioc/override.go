@@ -0,0 +1,136 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAlreadyBuilt is returned by Container.Override when the instance of a substituted provider was
+// already created, the substitution would not be seen by the instances depending on it.
+var ErrAlreadyBuilt = errors.New("instance already built")
+
+// Substitution replaces the instance of a provider in a container, see Replace and ReplaceNamed.
+type Substitution interface {
+	apply(c *Container) error
+}
+
+type namedKey struct {
+	group any
+	name  string
+}
+
+type substitution struct {
+	key     any
+	value   any
+	name    string
+	isBuilt func(c *Container) bool
+}
+
+func (s *substitution) apply(c *Container) error {
+	if s.isBuilt(c) {
+		return fmt.Errorf("override %s: %w", s.name, ErrAlreadyBuilt)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overrides == nil {
+		c.overrides = map[any]any{}
+	}
+	c.overrides[s.key] = s.value
+	return nil
+}
+
+// Replace returns a substitution which makes p resolve to v.
+func Replace[T any](p *Provider[T], v T) Substitution {
+	return &substitution{
+		key:     p,
+		value:   v,
+		name:    p.String(),
+		isBuilt: p.IsSet,
+	}
+}
+
+// ReplaceNamed returns a substitution which makes the provider registered under name in ps resolve to v,
+// it works whether or not the provider was already created by ps.GetProvider.
+func ReplaceNamed[T any, C comparable](ps *Providers[T, C], name string, v T) Substitution {
+	return &substitution{
+		key:   namedKey{group: ps, name: name},
+		value: v,
+		name:  typeName[T]() + "(" + name + ")",
+		isBuilt: func(c *Container) bool {
+			ps.mu.Lock()
+			p, ok := ps.ps[name]
+			ps.mu.Unlock()
+			return ok && p.IsSet(c)
+		},
+	}
+}
+
+// Override applies the substitutions to c, they are visible to c and its children. It fails with
+// ErrAlreadyBuilt if a substituted instance was already created, overrides must be applied before
+// anything resolves the provider.
+func (c *Container) Override(subs ...Substitution) error {
+	var errs Errors
+	for _, sub := range subs {
+		if err := sub.apply(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// override returns the substituted instance of p for c or one of its parents.
+func (c *Container) override(p injector, group any, key string) (any, bool) {
+	for ; c != nil; c = c.parent {
+		c.mu.Lock()
+		v, ok := c.overrides[p]
+		if !ok && group != nil {
+			v, ok = c.overrides[namedKey{group: group, name: key}]
+		}
+		c.mu.Unlock()
+		if ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Reset closes the instances of c and forgets them together with the overrides and the required
+// providers, so the container can be reused as if it was just created.
+func (c *Container) Reset() error {
+	err := c.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances = map[injector]any{}
+	c.order = nil
+	c.overrides = nil
+	c.deps = nil
+	c.required = nil
+	return err
+}
+
+// TestingT is the subset of testing.TB used by NewTestContainer.
+type TestingT interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+// NewTestContainer creates an isolated container with the substitutions applied, the container is closed
+// when the test finishes. Each test should use its own container, so tests can run in parallel.
+func NewTestContainer(t TestingT, subs ...Substitution) *Container {
+	t.Helper()
+	c := NewContainer()
+	if err := c.Override(subs...); err != nil {
+		t.Fatalf("override providers: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("close container: %s", err)
+		}
+	})
+	return c
+}
@@ -0,0 +1,67 @@
+package ioc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type fakeClient struct {
+	Name string
+}
+
+var fakeClients = NewProviders(func(name string, args ...string) *Provider[*fakeClient] {
+	return NewProvider(func(c *Container) (*fakeClient, error) {
+		return &fakeClient{Name: "real " + name + " " + args[0]}, nil
+	})
+})
+
+type fakeService struct {
+	Client *fakeClient
+}
+
+var fakeServiceProvider = NewProvider(func(c *Container) (*fakeService, error) {
+	client, err := fakeClients.GetProvider("redis", "localhost").Get(c)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeService{Client: client}, nil
+})
+
+func TestContainer_Override(t *testing.T) {
+	t.Parallel()
+	fake := &fakeClient{Name: "fake"}
+	c := NewTestContainer(t, ReplaceNamed(fakeClients, "redis", fake))
+
+	service := fakeServiceProvider.MustGet(c)
+	assert.Same(t, fake, service.Client)
+	assert.Same(t, fake, fakeClients.GetProvider("redis", "localhost").MustGet(c.Child()))
+
+	other := NewTestContainer(t)
+	assert.Equal(t, "real redis localhost", fakeServiceProvider.MustGet(other).Client.Name)
+}
+
+func TestContainer_OverrideAfterBuilt(t *testing.T) {
+	t.Parallel()
+	c := NewTestContainer(t)
+	fakeServiceProvider.MustGet(c)
+
+	err := c.Override(
+		Replace(fakeServiceProvider, &fakeService{}),
+		ReplaceNamed(fakeClients, "redis", &fakeClient{}),
+	)
+	assert.ErrorIs(t, err.(Errors)[0], ErrAlreadyBuilt)
+	assert.ErrorIs(t, err.(Errors)[1], ErrAlreadyBuilt)
+	assert.Equal(t, "override ioc.fakeService: instance already built\noverride ioc.fakeClient(redis): instance already built\n", err.Error())
+}
+
+func TestContainer_Reset(t *testing.T) {
+	t.Parallel()
+	c := NewTestContainer(t, Replace(fakeServiceProvider, &fakeService{}))
+	fakeClients.GetProvider("redis", "localhost").MustGet(c)
+
+	assert.NoError(t, c.Reset())
+	assert.Empty(t, c.GetAllInstances())
+
+	// the overrides are forgotten too
+	assert.Equal(t, "real redis localhost", fakeServiceProvider.MustGet(c).Client.Name)
+}
@@ -0,0 +1,135 @@
+package ioc
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+)
+
+type Generator[T any, C comparable] func(name string, args ...C) *Provider[T]
+
+// ArgsError is returned by Providers.Lookup when a name is reused with different args.
+type ArgsError struct {
+	Name    string
+	OldArgs []any
+	NewArgs []any
+}
+
+func (e *ArgsError) Error() string {
+	return fmt.Sprintf("provider %q already registered with args %v, got %v", e.Name, e.OldArgs, e.NewArgs)
+}
+
+// Providers is a collection of providers. It is used to manage named providers.
+type Providers[T any, C comparable] struct {
+	ps   map[string]*Provider[T]
+	args map[string][]C
+	fn   Generator[T, C]
+	mu   sync.Mutex
+}
+
+// NewProviders creates a new Providers instance.
+// The fn parameter is a function that creates a new provider for the given name.
+func NewProviders[T any, C comparable](fn Generator[T, C]) *Providers[T, C] {
+	return &Providers[T, C]{
+		ps:   map[string]*Provider[T]{},
+		args: map[string][]C{},
+		fn:   fn,
+	}
+}
+
+// GetProvider returns a provider with the given name, it is like Lookup but panics with an *ArgsError if
+// the name was registered with different args.
+func (ps *Providers[T, C]) GetProvider(name string, args ...C) *Provider[T] {
+	p, err := ps.Lookup(name, args...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Lookup returns a provider with the given name, the provider is created by the generator on first use.
+// The args of a name can not change, Lookup returns an *ArgsError if they differ from the registered ones.
+func (ps *Providers[T, C]) Lookup(name string, args ...C) (*Provider[T], error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	p, ok := ps.ps[name]
+	if !ok {
+		p = ps.fn(name, args...)
+		ps.register(name, p, args)
+		return p, nil
+	}
+	if !slices.Equal(args, ps.args[name]) {
+		return nil, &ArgsError{Name: name, OldArgs: toAny(ps.args[name]), NewArgs: toAny(args)}
+	}
+	return p, nil
+}
+
+func (ps *Providers[T, C]) register(name string, p *Provider[T], args []C) {
+	p.key = name
+	p.group = ps
+	ps.ps[name] = p
+	ps.args[name] = args
+}
+
+func toAny[C any](args []C) []any {
+	vs := make([]any, len(args))
+	for i, arg := range args {
+		vs[i] = arg
+	}
+	return vs
+}
+
+// Names returns the sorted names of the registered providers.
+func (ps *Providers[T, C]) Names() []string {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	names := make([]string, 0, len(ps.ps))
+	for name := range ps.ps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Args returns the args the provider with the given name was registered with.
+func (ps *Providers[T, C]) Args(name string) ([]C, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	args, ok := ps.args[name]
+	return args, ok
+}
+
+// All returns an iterator over the registered providers, sorted by name.
+func (ps *Providers[T, C]) All() iter.Seq2[string, *Provider[T]] {
+	return func(yield func(string, *Provider[T]) bool) {
+		for _, name := range ps.Names() {
+			ps.mu.Lock()
+			p, ok := ps.ps[name]
+			ps.mu.Unlock()
+			if ok && !yield(name, p) {
+				return
+			}
+		}
+	}
+}
+
+// Remove unregisters the provider with the given name, it returns false if there is no such provider.
+// Instances already created by the provider are kept by their containers, the next Lookup of the name
+// creates a new provider.
+func (ps *Providers[T, C]) Remove(name string) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	_, ok := ps.ps[name]
+	delete(ps.ps, name)
+	delete(ps.args, name)
+	return ok
+}
+
+// Replace registers p under the given name in place of the existing provider, if any. Like Remove, it
+// does not affect the instances already created by the replaced provider.
+func (ps *Providers[T, C]) Replace(name string, p *Provider[T], args ...C) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.register(name, p, args)
+}
@@ -0,0 +1,68 @@
+package ioc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newServerProviders() *Providers[string, string] {
+	return NewProviders(func(name string, args ...string) *Provider[string] {
+		return NewProvider(func(c *Container) (string, error) {
+			return name + ":" + args[0], nil
+		})
+	})
+}
+
+func TestProviders_Lookup(t *testing.T) {
+	ps := newServerProviders()
+
+	p, err := ps.Lookup("", "gin-server")
+	assert.NoError(t, err)
+	assert.Equal(t, ":gin-server", p.MustGet(NewContainer()))
+
+	same, err := ps.Lookup("", "gin-server")
+	assert.NoError(t, err)
+	assert.Same(t, p, same)
+
+	_, err = ps.Lookup("", "admin-server")
+	var ae *ArgsError
+	assert.ErrorAs(t, err, &ae)
+	assert.Equal(t, `provider "" already registered with args [gin-server], got [admin-server]`, err.Error())
+	assert.PanicsWithError(t, err.Error(), func() {
+		ps.GetProvider("", "admin-server")
+	})
+}
+
+func TestProviders_Names(t *testing.T) {
+	ps := newServerProviders()
+	ps.GetProvider("public", "gin-server")
+	ps.GetProvider("admin", "admin-server")
+
+	assert.Equal(t, []string{"admin", "public"}, ps.Names())
+	args, ok := ps.Args("admin")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"admin-server"}, args)
+
+	var names []string
+	for name, p := range ps.All() {
+		names = append(names, name)
+		assert.Equal(t, name, p.Key())
+	}
+	assert.Equal(t, []string{"admin", "public"}, names)
+}
+
+func TestProviders_RemoveReplace(t *testing.T) {
+	ps := newServerProviders()
+	old := ps.GetProvider("public", "gin-server")
+
+	assert.True(t, ps.Remove("public"))
+	assert.False(t, ps.Remove("public"))
+	assert.NotSame(t, old, ps.GetProvider("public", "other-server"))
+
+	custom := NewProvider(func(c *Container) (string, error) {
+		return "custom", nil
+	})
+	ps.Replace("public", custom, "custom-server")
+	assert.Same(t, custom, ps.GetProvider("public", "custom-server"))
+	assert.Equal(t, "string(public)", custom.String())
+}
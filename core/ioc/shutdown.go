@@ -0,0 +1,104 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ContextCloser is implemented by instances which need a context to be closed, the context carries the
+// deadline of Container.Shutdown.
+type ContextCloser interface {
+	Close(ctx context.Context) error
+}
+
+// Shutdowner is implemented by instances that shut down gracefully, such as http.Server.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// InstanceError records the instance for which an operation of the container failed.
+type InstanceError struct {
+	Op       string // operation, such as "close"
+	Provider string // name of the provider which created the instance
+	Err      error
+}
+
+func (e *InstanceError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Op, e.Provider, e.Err)
+}
+
+func (e *InstanceError) Unwrap() error {
+	return e.Err
+}
+
+// Close closes all instances without a deadline, see Shutdown.
+func (c *Container) Close() error {
+	return c.Shutdown(context.Background())
+}
+
+// Shutdown closes the instances stored in c in reverse creation order, so an instance is always closed
+// before the instances it depends on. Instances implementing Shutdowner, ContextCloser or io.Closer are
+// closed, the first matching interface in this order is used.
+//
+// The ctx deadline applies to the whole shutdown, instances that are not closed in time are reported with
+// the context error. Only the instances stored in c are closed, closing a child container leaves the
+// singletons of its parents untouched. The returned error is an Errors of *InstanceError.
+func (c *Container) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	order := make([]injector, len(c.order))
+	copy(order, c.order)
+	instances := make(map[injector]any, len(c.instances))
+	for p, ins := range c.instances {
+		instances[p] = ins
+	}
+	c.mu.Unlock()
+
+	var errs Errors
+	for i := len(order) - 1; i >= 0; i-- {
+		p := order[i]
+		closeFn := getCloseFunc(instances[p])
+		if closeFn == nil {
+			continue
+		}
+		if err := closeWithContext(ctx, closeFn); err != nil {
+			errs = append(errs, &InstanceError{Op: "close", Provider: p.String(), Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func getCloseFunc(ins any) func(ctx context.Context) error {
+	switch v := ins.(type) {
+	case Shutdowner:
+		return v.Shutdown
+	case ContextCloser:
+		return v.Close
+	case io.Closer:
+		return func(ctx context.Context) error {
+			return v.Close()
+		}
+	}
+	return nil
+}
+
+// closeWithContext calls fn and stops waiting for it once ctx is done, so an io.Closer which ignores
+// the context can not block the shutdown beyond its deadline.
+func closeWithContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,74 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type closeRecorder struct {
+	name   string
+	closed *[]string
+	err    error
+	wait   time.Duration
+}
+
+func (r *closeRecorder) Close() error {
+	time.Sleep(r.wait)
+	*r.closed = append(*r.closed, r.name)
+	return r.err
+}
+
+type shutdownRecorder struct {
+	closeRecorder
+}
+
+func (r *shutdownRecorder) Shutdown(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("missing deadline")
+	}
+	return r.Close()
+}
+
+func TestContainer_Shutdown(t *testing.T) {
+	var closed []string
+	db := NewProvider(func(c *Container) (*closeRecorder, error) {
+		return &closeRecorder{name: "db", closed: &closed}, nil
+	})
+	service := NewProvider(func(c *Container) (*shutdownRecorder, error) {
+		db.MustGet(c)
+		return &shutdownRecorder{closeRecorder{name: "service", closed: &closed, err: errors.New("flush failed")}}, nil
+	})
+
+	c := NewContainer()
+	service.MustGet(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := c.Shutdown(ctx)
+	assert.Equal(t, []string{"service", "db"}, closed)
+
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 1)
+	var ie *InstanceError
+	assert.True(t, errors.As(errs[0], &ie))
+	assert.Equal(t, "ioc.shutdownRecorder", ie.Provider)
+	assert.Equal(t, "close ioc.shutdownRecorder: flush failed", ie.Error())
+}
+
+func TestContainer_ShutdownDeadline(t *testing.T) {
+	var closed []string
+	slow := NewProvider(func(c *Container) (*closeRecorder, error) {
+		return &closeRecorder{name: "slow", closed: &closed, wait: time.Second}, nil
+	})
+	c := NewContainer()
+	slow.MustGet(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := c.Shutdown(ctx)
+	assert.ErrorIs(t, err.(Errors)[0], context.DeadlineExceeded)
+}
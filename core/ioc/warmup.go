@@ -0,0 +1,76 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultWarmupTimeout is the recommended timeout for Container.Warmup.
+const DefaultWarmupTimeout = 30 * time.Second
+
+// Dependency is a provider which can be resolved without knowing its type, *Provider[T] implements it.
+type Dependency interface {
+	fmt.Stringer
+	resolve(c *Container) error
+}
+
+func (p *Provider[T]) resolve(c *Container) error {
+	_, err := p.Get(c)
+	return err
+}
+
+// Require declares providers the application can not run without, they are built by Warmup. Named
+// providers are declared with their Providers collection:
+//
+//	c.Require(redis.Providers.GetProvider(redis.DefaultConfig), gorm.Providers.GetProvider(gorm.DefaultConfig))
+func (c *Container) Require(deps ...Dependency) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.required = append(c.required, deps...)
+}
+
+// Warmup builds all the required providers concurrently, so misconfiguration is discovered at boot
+// instead of on the first request. It waits until all of them are built or ctx is done and reports every
+// failure at once, as an Errors of *InstanceError. Providers still being built when ctx is done are
+// reported with the context error.
+func (c *Container) Warmup(ctx context.Context) error {
+	c.mu.Lock()
+	deps := make([]Dependency, len(c.required))
+	copy(deps, c.required)
+	c.mu.Unlock()
+
+	results := make([]chan error, len(deps))
+	for i, dep := range deps {
+		results[i] = make(chan error, 1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					results[i] <- fmt.Errorf("panic: %v", r)
+				}
+			}()
+			results[i] <- dep.resolve(c)
+		}()
+	}
+
+	var errs Errors
+	for i, result := range results {
+		var err error
+		select {
+		case err = <-result:
+		case <-ctx.Done():
+			select {
+			case err = <-result:
+			default:
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			errs = append(errs, &InstanceError{Op: "warmup", Provider: deps[i].String(), Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestContainer_Warmup(t *testing.T) {
+	t.Parallel()
+	errDSN := errors.New("bad dsn")
+	db := NewProvider(func(c *Container) (int, error) {
+		return 0, errDSN
+	}).WithName("DB")
+	cache := NewProvider(func(c *Container) (string, error) {
+		time.Sleep(time.Second)
+		return "cache", nil
+	}).WithName("Cache")
+	template := NewProvider(func(c *Container) (bool, error) {
+		panic("broken template")
+	}).WithName("Template")
+	jwt := NewProvider(func(c *Container) (float64, error) {
+		return 1, nil
+	}).WithName("JWT")
+
+	c := NewTestContainer(t)
+	c.Require(db, cache, template, jwt)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := c.Warmup(ctx)
+
+	errs := err.(Errors)
+	assert.Len(t, errs, 3)
+	assert.ErrorIs(t, errs[0], errDSN)
+	assert.ErrorIs(t, errs[1], context.DeadlineExceeded)
+	assert.Equal(t, "warmup Template: panic: broken template", errs[2].Error())
+	assert.True(t, jwt.IsSet(c))
+}
@@ -11,6 +11,16 @@ import (
 	"time"
 )
 
+// IdentityKey is the gin context key of the identity of the authenticated user, it is logged by the access
+// log of core/gin.
+const IdentityKey = "jwt.identity"
+
+// Identifier is implemented by the user types which can be identified in the logs, without exposing the
+// other fields of the token.
+type Identifier interface {
+	Identity() string
+}
+
 type customClaims[T any] struct {
 	T *T
 	jwt.RegisteredClaims
@@ -57,10 +67,17 @@ func (j *Auth[T]) Auth(c *gin.Context) {
 		return
 	}
 
-	c.Set(j.ctxKey, user)
+	j.setUser(c, user)
 	c.Next()
 }
 
+func (j *Auth[T]) setUser(c *gin.Context, user *T) {
+	c.Set(j.ctxKey, user)
+	if id, ok := any(user).(Identifier); ok {
+		c.Set(IdentityKey, id.Identity())
+	}
+}
+
 func (j *Auth[T]) GenerateToken(user *T) (string, error) {
 	// 创建自定义 Claims
 	claims := customClaims[T]{
@@ -108,7 +125,7 @@ func (j *Auth[T]) GetUser(c *gin.Context) *T {
 		if err != nil {
 			return nil
 		}
-		c.Set(j.ctxKey, u)
+		j.setUser(c, u)
 		return u
 	}
 	return user.(*T)
@@ -12,7 +12,7 @@ var initConfig = `
 # JWT configuration file
 
 # secret key, should always set in environment variable
-# for example: export JWT_SECRET=your_secret
+# for example: export JWT_SECRET=your_secret, or reference a secret: "${file:/run/secrets/jwt_secret}"
 secret: "your_secret"
 
 # sign method, support HS256, HS384, HS512, RS256, RS384, RS512, ES256, ES384, ES512
@@ -26,10 +26,10 @@ expires: "168h"
 `
 
 type Config struct {
-	Secret     string        `mapstructure:"secret"`
-	SignMethod string        `mapstructure:"sign_method"`
-	Scheme     string        `mapstructure:"scheme"`
-	Expires    time.Duration `mapstructure:"expires"`
+	Secret     string        `mapstructure:"secret" validate:"required"`
+	SignMethod string        `mapstructure:"sign_method" validate:"oneof=HS256 HS384 HS512 RS256 RS384 RS512 ES256 ES384 ES512"`
+	Scheme     string        `mapstructure:"scheme" validate:"required"`
+	Expires    time.Duration `mapstructure:"expires" validate:"min=1s"`
 }
 
 var auths = sync.Map{}
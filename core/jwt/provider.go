@@ -1,11 +1,14 @@
 package jwt
 
 import (
-	"fmt"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/viper"
 )
 
+func init() {
+	viper.RegisterDefault(DefaultConfig, initConfig)
+}
+
 var Providers = ioc.NewProviders(func(name string, args ...string) *ioc.Provider[*Config] {
 	return ioc.NewProvider(func(c *ioc.Container) (*Config, error) {
 		vp, err := viper.GetViper(name, initConfig, c)
@@ -13,9 +16,9 @@ var Providers = ioc.NewProviders(func(name string, args ...string) *ioc.Provider
 			return nil, err
 		}
 		var cfg Config
-		err = vp.Unmarshal(&cfg)
+		err = viper.Unmarshal(name, vp, &cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal '%s' config: %w", name, err)
+			return nil, err
 		}
 		return &cfg, nil
 	})
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"github.com/aiechoic/admin/core/gins"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/viper"
+	"github.com/aiechoic/admin/pkg/metrics"
+)
+
+const DefaultConfig = "metrics"
+
+var initConfig = `
+# Metrics configuration, the metrics are served in the prometheus text format
+
+# path of the metrics endpoint, relative to the api root
+path: "/metrics"
+
+# bearer token required to scrape the metrics, can be referenced as "${env:NAME}" or
+# "${file:/run/secrets/NAME}", or encrypted as "enc:..."
+token: ""
+
+# basic auth credentials required to scrape the metrics, only used when the token is empty, the metrics
+# are public when both are empty
+username: ""
+password: ""
+`
+
+type Config struct {
+	Path     string `mapstructure:"path" validate:"required,startswith=/"`
+	Token    string `mapstructure:"token"`
+	Username string `mapstructure:"username" validate:"required_with=Password"`
+	Password string `mapstructure:"password" validate:"required_with=Username"`
+}
+
+// Metrics serves the metrics of a registry, see NewService.
+type Metrics struct {
+	// path of the metrics endpoint, relative to the api root
+	Path string
+	// security of the metrics endpoint, it can be replaced, such as by a jwt auth
+	Security gins.Security
+	Registry *metrics.Registry
+}
+
+func init() {
+	viper.RegisterDefault(DefaultConfig, initConfig)
+}
+
+var Providers = ioc.NewProviders(func(name string, args ...any) *ioc.Provider[*Metrics] {
+	return ioc.NewProvider(func(c *ioc.Container) (*Metrics, error) {
+		vp, err := viper.GetViper(name, initConfig, c)
+		if err != nil {
+			return nil, err
+		}
+		var cfg Config
+		err = viper.Unmarshal(name, vp, &cfg)
+		if err != nil {
+			return nil, err
+		}
+		var security gins.Security = gins.NoSecurity
+		if cfg.Token != "" {
+			security = &bearerAuth{token: cfg.Token}
+		} else if cfg.Username != "" {
+			security = &basicAuth{username: cfg.Username, password: cfg.Password}
+		}
+		return &Metrics{
+			Path:     cfg.Path,
+			Security: security,
+			Registry: metrics.DefaultRegistry,
+		}, nil
+	})
+})
+
+func GetMetrics(name string, c *ioc.Container) (*Metrics, error) {
+	return Providers.GetProvider(name).Get(c)
+}
+
+func GetDefaultMetrics(c *ioc.Container) (*Metrics, error) {
+	return GetMetrics(DefaultConfig, c)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"github.com/aiechoic/admin/core/openapi"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const (
+	bearerScheme = "MetricsBearer"
+	basicScheme  = "MetricsBasic"
+)
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// bearerAuth requires the configured token in the Authorization header, as configured in the
+// authorization section of the prometheus scrape config.
+type bearerAuth struct {
+	token string
+}
+
+func (a *bearerAuth) Auth(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || !equal(token, a.token) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+func (a *bearerAuth) SecuritySchemes() openapi.SecuritySchemes {
+	return openapi.SecuritySchemes{
+		bearerScheme: &openapi.SecurityScheme{
+			Type:   openapi.SecuritySchemeTypeHttp,
+			Scheme: "bearer",
+		},
+	}
+}
+
+func (a *bearerAuth) SecurityRequirement() map[string][]string {
+	return map[string][]string{
+		bearerScheme: {},
+	}
+}
+
+// basicAuth requires the configured credentials, as configured in the basic_auth section of the
+// prometheus scrape config.
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (a *basicAuth) Auth(c *gin.Context) {
+	username, password, ok := c.Request.BasicAuth()
+	// both are compared to take the same time whichever is wrong
+	usernameOk := equal(username, a.username)
+	passwordOk := equal(password, a.password)
+	if !ok || !usernameOk || !passwordOk {
+		c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+func (a *basicAuth) SecuritySchemes() openapi.SecuritySchemes {
+	return openapi.SecuritySchemes{
+		basicScheme: &openapi.SecurityScheme{
+			Type:   openapi.SecuritySchemeTypeHttp,
+			Scheme: "basic",
+		},
+	}
+}
+
+func (a *basicAuth) SecurityRequirement() map[string][]string {
+	return map[string][]string{
+		basicScheme: {},
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"github.com/aiechoic/admin/core/gins"
+	"github.com/aiechoic/admin/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+// NewService creates a service serving the metrics in the prometheus text format, including the http
+// requests, the database queries, the redis commands and the cache lookups.
+func NewService(m *Metrics) *gins.Service {
+	return &gins.Service{
+		Tag:         "Metrics",
+		Description: "Metrics of the application in the prometheus text format",
+		Path:        m.Path,
+		Security:    m.Security,
+		Routes: []gins.Route{
+			{
+				Method:      "GET",
+				Path:        "",
+				Summary:     "Metrics",
+				Description: "Scraped by prometheus",
+				Handler: gins.Handler{
+					Response: gins.Response{
+						Description: "Metrics in the prometheus text format",
+						Contents:    gins.ContentsTextPlain,
+					},
+					Handle: func(c *gin.Context) {
+						c.Header("Content-Type", metrics.ContentType)
+						c.Status(http.StatusOK)
+						if err := m.Registry.WriteText(c.Writer); err != nil {
+							log.Printf("failed to write metrics: %v", err)
+						}
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"github.com/aiechoic/admin/core/gins"
+	"github.com/aiechoic/admin/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestEngine(security gins.Security) (*gin.Engine, *metrics.Registry) {
+	registry := metrics.NewRegistry()
+	service := NewService(&Metrics{Path: "/metrics", Security: security, Registry: registry})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET(service.Path, service.Security.Auth, service.Routes[0].Handler.Handle)
+	return r, registry
+}
+
+func TestService(t *testing.T) {
+	r, registry := newTestEngine(gins.NoSecurity)
+	requests := registry.NewCounter("requests_total", "Number of the requests.", "route")
+	latency := registry.NewHistogram("latency_seconds", "Latency\nof the requests.", []float64{0.1, 1}, "route")
+	requests.Inc(`/users/:id`)
+	requests.Add(2, `/say/"hi"`)
+	latency.Observe(0.05, "/users/:id")
+	latency.Observe(0.5, "/users/:id")
+	latency.Observe(5, "/users/:id")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metrics.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP latency_seconds Latency\nof the requests.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/users/:id",le="0.1"} 1
+latency_seconds_bucket{route="/users/:id",le="1"} 2
+latency_seconds_bucket{route="/users/:id",le="+Inf"} 3
+latency_seconds_sum{route="/users/:id"} 5.55
+latency_seconds_count{route="/users/:id"} 3
+# HELP requests_total Number of the requests.
+# TYPE requests_total counter
+requests_total{route="/say/\"hi\""} 2
+requests_total{route="/users/:id"} 1
+`, w.Body.String())
+}
+
+func TestService_Auth(t *testing.T) {
+	r, _ := newTestEngine(&basicAuth{username: "prometheus", password: "secret"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("prometheus", "secret")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	r, _ = newTestEngine(&bearerAuth{token: "secret"})
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req.Header.Set("Authorization", "Bearer secret")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -102,8 +102,15 @@ type Parameter struct {
 	AllowEmptyValue bool    `json:"allowEmptyValue,omitempty"`
 }
 
+// Header is a response header, see https://swagger.io/specification/#header-object
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
 type ResponseBody struct {
 	Description string                     `json:"description"`
+	Headers     map[string]*Header         `json:"headers,omitempty"`
 	Content     map[ContentType]*MediaType `json:"content,omitempty"`
 }
 
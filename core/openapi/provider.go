@@ -1,7 +1,6 @@
 package openapi
 
 import (
-	"fmt"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/viper"
 )
@@ -29,6 +28,10 @@ servers:
 # no need to set other fields, it will be generated automatically.
 `
 
+func init() {
+	viper.RegisterDefault(DefaultConfig, initConfig)
+}
+
 var Providers = ioc.NewProviders[*Openapi](func(name string, a ...any) *ioc.Provider[*Openapi] {
 	return ioc.NewProvider(func(c *ioc.Container) (*Openapi, error) {
 		vp, err := viper.GetViper(name, initConfig, c)
@@ -37,9 +40,9 @@ var Providers = ioc.NewProviders[*Openapi](func(name string, a ...any) *ioc.Prov
 		}
 
 		var api Openapi
-		err = vp.Unmarshal(&api)
+		err = viper.Unmarshal(name, vp, &api)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal '%s' config: %w", name, err)
+			return nil, err
 		}
 		if api.Paths == nil {
 			api.Paths = make(map[string]PathItem)
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"fmt"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/viper"
+)
+
+const DefaultConfig = "ratelimit"
+
+var initConfig = `
+# Rate limit configuration
+
+# limiter driver, "redis" to share the limits between the instances, registered by core/redis, or "memory"
+# for a single instance
+driver: "redis"
+
+# redis config used by the redis driver
+redis: "redis"
+
+# redis key prefix
+key: "ratelimit:"
+`
+
+type Config struct {
+	Driver string `mapstructure:"driver" validate:"required"`
+	Redis  string `mapstructure:"redis" validate:"required_if=Driver redis"`
+	Key    string `mapstructure:"key"`
+}
+
+func init() {
+	viper.RegisterDefault(DefaultConfig, initConfig)
+	ioc.RegisterConfigDriver("memory", func(c *ioc.Container, cfg *Config) (Limiter, error) {
+		return NewMemoryLimiter(), nil
+	})
+}
+
+func getConfig(name string, c *ioc.Container) (*Config, error) {
+	vp, err := viper.GetViper(name, initConfig, c)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	err = viper.Unmarshal(name, vp, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Providers defines the providers for the limiters, the implementation is selected by the "driver" config
+// key among the Limiter drivers, more can be registered with ioc.RegisterConfigDriver taking the decoded
+// *Config.
+var Providers = ioc.NewProviders(func(name string, args ...any) *ioc.Provider[Limiter] {
+	return ioc.NewProvider(func(c *ioc.Container) (Limiter, error) {
+		cfg, err := getConfig(name, c)
+		if err != nil {
+			return nil, err
+		}
+		limiter, err := ioc.NewConfigDriver[Limiter](cfg.Driver, c, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config '%s': %w", name, err)
+		}
+		return limiter, nil
+	})
+})
+
+func GetLimiter(name string, c *ioc.Container) (Limiter, error) {
+	return Providers.GetProvider(name).Get(c)
+}
+
+func GetDefaultLimiter(c *ioc.Container) (Limiter, error) {
+	return GetLimiter(DefaultConfig, c)
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Result is the decision of a Limiter for a request.
+type Result struct {
+	Allowed bool
+	// maximum number of requests in the window
+	Limit int
+	// number of requests still allowed in the current window
+	Remaining int
+	// time until the oldest request of the window expires and a request is allowed again
+	Reset time.Duration
+}
+
+// Limiter counts the requests of the clients in a sliding window: a request is allowed if fewer than limit
+// requests of the same key were allowed during the last window.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error)
+}
+
+const sweepInterval = 1024
+
+// MemoryLimiter is a Limiter keeping the requests in memory, it is meant for single instance deployments
+// and tests. The keys without recent requests are removed periodically.
+type MemoryLimiter struct {
+	keys  map[string]*memoryWindow
+	calls int
+	mu    sync.Mutex
+}
+
+type memoryWindow struct {
+	// times of the allowed requests, the oldest first
+	times  []time.Time
+	window time.Duration
+}
+
+// expire removes the requests which left the window.
+func (w *memoryWindow) expire(now time.Time) {
+	i := 0
+	for i < len(w.times) && !w.times[i].After(now.Add(-w.window)) {
+		i++
+	}
+	w.times = w.times[i:]
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{keys: map[string]*memoryWindow{}}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	if l.calls%sweepInterval == 0 {
+		l.sweep(now)
+	}
+	w, ok := l.keys[key]
+	if !ok {
+		w = &memoryWindow{}
+		l.keys[key] = w
+	}
+	w.window = window
+	w.expire(now)
+	allowed := len(w.times) < limit
+	if allowed {
+		w.times = append(w.times, now)
+	}
+	result := &Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(limit-len(w.times), 0),
+	}
+	if len(w.times) > 0 {
+		result.Reset = w.times[0].Add(window).Sub(now)
+	}
+	return result, nil
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, w := range l.keys {
+		w.expire(now)
+		if len(w.times) == 0 {
+			delete(l.keys, key)
+		}
+	}
+}
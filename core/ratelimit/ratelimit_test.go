@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	l := NewMemoryLimiter()
+	ctx := context.Background()
+	window := 50 * time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		r, err := l.Allow(ctx, "a", 2, window)
+		assert.NoError(t, err)
+		assert.True(t, r.Allowed)
+		assert.Equal(t, 1-i, r.Remaining)
+	}
+	r, err := l.Allow(ctx, "a", 2, window)
+	assert.NoError(t, err)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 0, r.Remaining)
+	assert.True(t, r.Reset > 0 && r.Reset <= window)
+
+	// the keys are limited separately
+	r, err = l.Allow(ctx, "b", 2, window)
+	assert.NoError(t, err)
+	assert.True(t, r.Allowed)
+
+	time.Sleep(window)
+	r, err = l.Allow(ctx, "a", 2, window)
+	assert.NoError(t, err)
+	assert.True(t, r.Allowed)
+
+	l.sweep(time.Now().Add(window))
+	assert.Empty(t, l.keys)
+}
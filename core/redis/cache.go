@@ -3,19 +3,22 @@ package redis
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/viper"
 	"github.com/redis/go-redis/v9"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var initCacheConfig = `
 # Cache configuration
 
+# cache store driver, "redis" or "memory" for a single instance without redis
+driver: "redis"
+
 # redis key prefix
 key: "$name"
 
@@ -31,23 +34,36 @@ var CacheProviders = ioc.NewProviders(func(name string, args ...string) *ioc.Pro
 			return nil, err
 		}
 		var cfg CacheConfig
-		err = vp.Unmarshal(&cfg)
+		err = viper.Unmarshal(name, vp, &cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal '%s' config: %w", name, err)
-		}
-		if cfg.Key == "" {
-			return nil, fmt.Errorf("config '%s': cache key can not be empty", name)
+			return nil, err
 		}
 		return &cfg, nil
 	})
 })
 
+func init() {
+	ioc.RegisterDriver("redis", func(c *ioc.Container, name string) (Store, error) {
+		rds, err := GetClient(name, c)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisStore(rds), nil
+	})
+	ioc.RegisterDriver("memory", func(c *ioc.Container, name string) (Store, error) {
+		return NewMemoryStore(), nil
+	})
+}
+
+// GetCache returns a cache configured by cacheConfig, the store is selected by the "driver" config key
+// among the Store drivers, which receive redisConfig as the name of their config. The redis store uses the
+// client configured by redisConfig.
 func GetCache[T any](cacheConfig, redisConfig string, c *ioc.Container) (*Cache[T], error) {
 	cfg, err := CacheProviders.GetProvider(cacheConfig).Get(c)
 	if err != nil {
 		return nil, err
 	}
-	return newCache[T](cfg, redisConfig, c)
+	return newCache[T](cacheConfig, cfg, redisConfig, c)
 }
 
 func GetDefaultCache[T any](cacheConfig string, c *ioc.Container) (*Cache[T], error) {
@@ -56,37 +72,66 @@ func GetDefaultCache[T any](cacheConfig string, c *ioc.Container) (*Cache[T], er
 
 var caches = sync.Map{}
 
-func newCache[T any](c *CacheConfig, redisConfig string, container *ioc.Container) (*Cache[T], error) {
-	cache, ok := caches.Load(c)
-	if ok {
+func newCache[T any](name string, c *CacheConfig, redisConfig string, container *ioc.Container) (*Cache[T], error) {
+	if cache, ok := caches.Load(c); ok {
 		return cache.(*Cache[T]), nil
 	}
-	rds, err := GetClient(redisConfig, container)
+	driver := c.Driver
+	if driver == "" {
+		driver = "redis"
+	}
+	store, err := ioc.NewDriver[Store](driver, container, redisConfig)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("cache '%s': %w", c.Key, err)
+	}
+	cache, loaded := caches.LoadOrStore(c, NewStoreCache[T](store, c.Key, c.Expiration))
+	if !loaded {
+		// the expiration follows the config file, changing the driver or the key requires a restart
+		_, err = viper.OnConfigChange(name, container, cache.(*Cache[T]).reload)
+		if err != nil {
+			return nil, err
+		}
 	}
-	cache = NewCache[T](rds, c.Key, c.Expiration)
-	caches.Store(c, cache)
 	return cache.(*Cache[T]), nil
 }
 
 type CacheConfig struct {
-	Key        string
-	Expiration time.Duration
+	Driver     string        `mapstructure:"driver"`
+	Key        string        `mapstructure:"key" validate:"required"`
+	Expiration time.Duration `mapstructure:"expiration" validate:"min=0"`
 }
 
 type Cache[T any] struct {
-	rds        *redis.Client
+	store      Store
 	key        string
-	expiration time.Duration
+	expiration atomic.Int64
 }
 
 func NewCache[T any](rds *redis.Client, key string, expiration time.Duration) *Cache[T] {
-	return &Cache[T]{
-		rds:        rds,
-		key:        key,
-		expiration: expiration,
+	return NewStoreCache[T](NewRedisStore(rds), key, expiration)
+}
+
+// NewStoreCache creates a cache which keeps its values in the given store.
+func NewStoreCache[T any](store Store, key string, expiration time.Duration) *Cache[T] {
+	c := &Cache[T]{
+		store: store,
+		key:   key,
+	}
+	c.expiration.Store(int64(expiration))
+	return c
+}
+
+// SetExpiration changes the expiration of the values set from now on.
+func (c *Cache[T]) SetExpiration(expiration time.Duration) {
+	c.expiration.Store(int64(expiration))
+}
+
+func (c *Cache[T]) reload(cfg *CacheConfig) error {
+	if cfg.Expiration < 0 {
+		return fmt.Errorf("cache expiration can not be negative")
 	}
+	c.SetExpiration(cfg.Expiration)
+	return nil
 }
 
 func (c *Cache[T]) Set(id string, value T) error {
@@ -94,21 +139,23 @@ func (c *Cache[T]) Set(id string, value T) error {
 	if err != nil {
 		return err
 	}
-	return c.rds.Set(context.Background(), c.key+id, data, c.expiration).Err()
+	return c.store.Set(context.Background(), c.key+id, data, time.Duration(c.expiration.Load()))
 }
 
 // Get returns the value of the key. If the key does not exist, nil is returned.
 func (c *Cache[T]) Get(id string) (*T, error) {
 	key := c.key + id
-	data, err := c.rds.Get(context.Background(), key).Result()
+	data, err := c.store.Get(context.Background(), key)
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return nil, nil
-		}
 		return nil, err
 	}
+	if data == nil {
+		cacheRequests.Inc(c.key, "miss")
+		return nil, nil
+	}
+	cacheRequests.Inc(c.key, "hit")
 	var value T
-	err = json.Unmarshal([]byte(data), &value)
+	err = json.Unmarshal(data, &value)
 	if err != nil {
 		return nil, err
 	}
@@ -116,5 +163,5 @@ func (c *Cache[T]) Get(id string) (*T, error) {
 }
 
 func (c *Cache[T]) Del(id string) error {
-	return c.rds.Del(context.Background(), c.key+id).Err()
+	return c.store.Del(context.Background(), c.key+id)
 }
@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreCache(t *testing.T) {
+	cache := NewStoreCache[string](NewMemoryStore(), "code:", 50*time.Millisecond)
+
+	v, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	assert.NoError(t, cache.Set("a", "1234"))
+	v, err = cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", *v)
+
+	time.Sleep(60 * time.Millisecond)
+	v, err = cache.Get("a")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	assert.NoError(t, cache.Set("b", "5678"))
+	assert.NoError(t, cache.Del("b"))
+	v, err = cache.Get("b")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestCache_reload(t *testing.T) {
+	cache := NewStoreCache[string](NewMemoryStore(), "code:", time.Minute)
+
+	assert.NoError(t, cache.reload(&CacheConfig{Key: "code:", Expiration: time.Hour}))
+	assert.Equal(t, int64(time.Hour), cache.expiration.Load())
+
+	assert.Error(t, cache.reload(&CacheConfig{Key: "code:", Expiration: -time.Second}))
+	assert.Equal(t, int64(time.Hour), cache.expiration.Load())
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/aiechoic/admin/pkg/metrics"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+var (
+	commandDuration = metrics.NewHistogram(
+		"redis_command_duration_seconds",
+		"Duration of the redis commands by redis config and command, pipelines are recorded as a whole.",
+		metrics.DefBuckets,
+		"client", "command",
+	)
+	commandErrors = metrics.NewCounter(
+		"redis_command_errors_total",
+		"Number of the failed redis commands by redis config and command, missing keys are not errors.",
+		"client", "command",
+	)
+	cacheRequests = metrics.NewCounter(
+		"cache_requests_total",
+		"Number of the cache lookups by cache key prefix and result, hit or miss.",
+		"cache", "result",
+	)
+)
+
+// metricsHook records the duration of the commands of a client, labelled by the name of its config.
+type metricsHook struct {
+	name string
+}
+
+func (h metricsHook) observe(command string, start time.Time, err error) {
+	commandDuration.Observe(time.Since(start).Seconds(), h.name, command)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		commandErrors.Inc(h.name, command)
+	}
+}
+
+func (h metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (h metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+		return err
+	}
+}
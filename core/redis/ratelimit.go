@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/ratelimit"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+func init() {
+	ioc.RegisterConfigDriver("redis", func(c *ioc.Container, cfg *ratelimit.Config) (ratelimit.Limiter, error) {
+		rds, err := GetClient(cfg.Redis, c)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisLimiter(rds, cfg.Key), nil
+	})
+}
+
+// slidingWindow keeps the allowed requests of a key in a sorted set scored by their time in microseconds,
+// the time of the redis server is used so that the instances agree on the window. It returns whether the
+// request is allowed, the number of requests in the window and the time of the oldest one.
+var slidingWindow = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local member = ARGV[3]
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, math.ceil(window / 1000))
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local reset = 0
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// RedisLimiter is a ratelimit.Limiter keeping the requests in redis, the limits are shared by all the instances
+// using the same redis.
+type RedisLimiter struct {
+	rds    *redis.Client
+	prefix string
+}
+
+// NewRedisLimiter creates a limiter whose redis keys start with prefix.
+func NewRedisLimiter(rds *redis.Client, prefix string) *RedisLimiter {
+	return &RedisLimiter{rds: rds, prefix: prefix}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*ratelimit.Result, error) {
+	res, err := slidingWindow.Run(ctx, l.rds, []string{l.prefix + key}, limit, window.Microseconds(), uuid.NewString()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return &ratelimit.Result{
+		Allowed:   res[0] == 1,
+		Limit:     limit,
+		Remaining: max(limit-int(res[1]), 0),
+		Reset:     time.Duration(res[2]) * time.Microsecond,
+	}, nil
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"github.com/aiechoic/admin/core/health"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/viper"
 	"github.com/redis/go-redis/v9"
@@ -33,15 +34,15 @@ db: 9
 # Username for Redis server
 username: ""
 
-# Password for Redis server
+# Password for Redis server, can be referenced as "${env:NAME}" or "${file:/run/secrets/NAME}", or encrypted as "enc:..."
 password: ""
 `
 
 type Config struct {
 	Debug    bool   `mapstructure:"debug"`
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	DB       int    `mapstructure:"db"`
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     int    `mapstructure:"port" validate:"min=1,max=65535"`
+	DB       int    `mapstructure:"db" validate:"min=0"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
 }
@@ -56,9 +57,9 @@ var Providers = ioc.NewProviders(func(name string, args ...any) *ioc.Provider[*r
 		}
 
 		var cfg Config
-		err = vp.Unmarshal(&cfg)
+		err = viper.Unmarshal(name, vp, &cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal '%s' config: %w", name, err)
+			return nil, err
 		}
 
 		client := redis.NewClient(&redis.Options{
@@ -70,9 +71,11 @@ var Providers = ioc.NewProviders(func(name string, args ...any) *ioc.Provider[*r
 
 		err = client.Ping(context.Background()).Err()
 		if err != nil {
+			client.Close()
 			return nil, fmt.Errorf("failed to ping redis: %w", err)
 		}
 
+		client.AddHook(metricsHook{name: name})
 		if cfg.Debug {
 			client.AddHook(redisHook{
 				logger: log.New(log.Writer(), "redis: ", log.LstdFlags),
@@ -82,6 +85,31 @@ var Providers = ioc.NewProviders(func(name string, args ...any) *ioc.Provider[*r
 	})
 })
 
+func init() {
+	viper.RegisterDefault(DefaultConfig, initConfig)
+	health.RegisterAdapter(func(ins any) (health.Checker, bool) {
+		client, ok := ins.(*redis.Client)
+		if !ok {
+			return nil, false
+		}
+		return &clientChecker{client: client}, true
+	})
+}
+
+// clientChecker checks the health of a redis client with PING.
+type clientChecker struct {
+	client *redis.Client
+}
+
+func (c *clientChecker) CheckHealth(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+func (c *clientChecker) HealthName() string {
+	opts := c.client.Options()
+	return fmt.Sprintf("redis(%s/%d)", opts.Addr, opts.DB)
+}
+
 func GetClient(name string, c *ioc.Container) (*redis.Client, error) {
 	return Providers.GetProvider(name).Get(c)
 }
@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// Store is the storage of a Cache.
+type Store interface {
+	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
+	// Get returns nil without error if the key does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Del(ctx context.Context, key string) error
+}
+
+// RedisStore is a Store backed by redis.
+type RedisStore struct {
+	rds *redis.Client
+}
+
+func NewRedisStore(rds *redis.Client) *RedisStore {
+	return &RedisStore{rds: rds}
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return s.rds.Set(ctx, key, value, expiration).Err()
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.rds.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *RedisStore) Del(ctx context.Context, key string) error {
+	return s.rds.Del(ctx, key).Err()
+}
+
+type memoryItem struct {
+	value    []byte
+	expireAt time.Time // zero means never
+}
+
+// MemoryStore is a Store keeping the values in memory, it is meant for single instance deployments and
+// tests. Expired values are removed lazily.
+type MemoryStore struct {
+	items map[string]memoryItem
+	mu    sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: map[string]memoryItem{}}
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := memoryItem{value: append([]byte(nil), value...)}
+	if expiration > 0 {
+		item.expireAt = time.Now().Add(expiration)
+	}
+	s.items[key] = item
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	if !item.expireAt.IsZero() && time.Now().After(item.expireAt) {
+		delete(s.items, key)
+		return nil, nil
+	}
+	return append([]byte(nil), item.value...), nil
+}
+
+func (s *MemoryStore) Del(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
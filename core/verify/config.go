@@ -24,10 +24,10 @@ length: 6
 `
 
 type Config struct {
-	RedisKey     string `mapstructure:"redis_key"`
-	Expire       int    `mapstructure:"expire"`
-	RandomCharts string `mapstructure:"random_charts"`
-	Length       int    `mapstructure:"length"`
+	RedisKey     string `mapstructure:"redis_key" validate:"required"`
+	Expire       int    `mapstructure:"expire" validate:"min=1"`
+	RandomCharts string `mapstructure:"random_charts" validate:"required"`
+	Length       int    `mapstructure:"length" validate:"min=1"`
 }
 
 func (c *Config) NewVerification(rds *redis.Client, sender Sender) *Verification {
@@ -1,13 +1,16 @@
 package verify
 
 import (
-	"fmt"
 	"github.com/aiechoic/admin/core/email"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/redis"
 	"github.com/aiechoic/admin/core/viper"
 )
 
+func init() {
+	viper.RegisterDefault(DefaultConfig, initConfig)
+}
+
 var Providers = ioc.NewProviders[*Verification](func(name string, args ...string) *ioc.Provider[*Verification] {
 	return ioc.NewProvider(func(c *ioc.Container) (*Verification, error) {
 		redisConfig := args[0]
@@ -26,16 +29,20 @@ var Providers = ioc.NewProviders[*Verification](func(name string, args ...string
 			return nil, err
 		}
 		var cfg Config
-		err = vp.Unmarshal(&cfg)
+		err = viper.Unmarshal(name, vp, &cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal '%s' config: %w", name, err)
+			return nil, err
 		}
 		return cfg.NewVerification(rds, sender), nil
 	})
 })
 
 func GetEmailVerification(name, redisConfig, emailConfig string, c *ioc.Container) (*Verification, error) {
-	return Providers.GetProvider(name, redisConfig, emailConfig).Get(c)
+	p, err := Providers.Lookup(name, redisConfig, emailConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(c)
 }
 
 func GetDefaultVerification(c *ioc.Container) (*Verification, error) {
@@ -0,0 +1,82 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+// Validator returns the validator of the config structs, custom rules can be registered on it. The field
+// names of its errors are the mapstructure keys.
+func Validator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return strings.ToLower(f.Name)
+			}
+			return name
+		})
+	})
+	return validate
+}
+
+// Unmarshal decodes the named config into cfg, it rejects the keys which do not match a field of cfg,
+// usually typos, and validates cfg with the "validate" struct tags of github.com/go-playground/validator:
+//
+//	type Config struct {
+//		HttpPort int `mapstructure:"http_port" validate:"min=1,max=65535"`
+//	}
+//
+// The errors name the config file, the key and the failed rule, with the value unless the key holds a
+// secret, see IsSecret.
+func Unmarshal(name string, v *viper.Viper, cfg any) error {
+	err := v.UnmarshalExact(cfg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", describe(name, v), err)
+	}
+	err = Validator().Struct(cfg)
+	if err == nil {
+		return nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return fmt.Errorf("%s: %w", describe(name, v), err)
+	}
+	typeName := reflect.Indirect(reflect.ValueOf(cfg)).Type().Name()
+	msgs := make([]string, len(verrs))
+	for i, fe := range verrs {
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		// the namespace starts with the name of the struct, if it has one
+		key := strings.TrimPrefix(fe.Namespace(), typeName+".")
+		value := fe.Value()
+		if IsSecret(key) {
+			value = Redacted
+		}
+		msgs[i] = fmt.Sprintf("key '%s' fails rule '%s', got %v", key, rule, value)
+	}
+	return fmt.Errorf("%s: %s", describe(name, v), strings.Join(msgs, "; "))
+}
+
+func describe(name string, v *viper.Viper) string {
+	if file := v.ConfigFileUsed(); file != "" {
+		return fmt.Sprintf("config '%s' (%s)", name, file)
+	}
+	return fmt.Sprintf("config '%s'", name)
+}
@@ -0,0 +1,72 @@
+package viper
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+type decodeConfig struct {
+	HttpPort int           `mapstructure:"http_port" validate:"min=1,max=65535"`
+	Mode     string        `mapstructure:"mode" validate:"omitempty,oneof=debug release"`
+	Timeout  time.Duration `mapstructure:"timeout" validate:"min=1s"`
+	Db       struct {
+		Host     string `mapstructure:"host" validate:"required"`
+		Password string `mapstructure:"password" validate:"omitempty,min=8"`
+	} `mapstructure:"db"`
+}
+
+func newDecodeViper(t *testing.T, config string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(strings.NewReader(config)))
+	return v
+}
+
+func TestUnmarshal(t *testing.T) {
+	var cfg decodeConfig
+	v := newDecodeViper(t, `
+http_port: 8080
+mode: "release"
+timeout: "3s"
+db:
+  host: "localhost"
+`)
+	assert.NoError(t, Unmarshal("decode", v, &cfg))
+	assert.Equal(t, 8080, cfg.HttpPort)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+
+	v = newDecodeViper(t, `
+http_prot: 8080
+timeout: "3s"
+db:
+  host: "localhost"
+`)
+	err := Unmarshal("decode", v, &decodeConfig{})
+	assert.ErrorContains(t, err, "config 'decode': ")
+	assert.ErrorContains(t, err, "invalid keys: http_prot")
+
+	v = newDecodeViper(t, `
+http_port: 0
+mode: "test"
+timeout: "3s"
+db:
+  host: ""
+`)
+	err = Unmarshal("decode", v, &decodeConfig{})
+	assert.EqualError(t, err, "config 'decode': key 'http_port' fails rule 'min=1', got 0; "+
+		"key 'mode' fails rule 'oneof=debug release', got test; key 'db.host' fails rule 'required', got ")
+
+	// the secret values are not printed
+	v = newDecodeViper(t, `
+http_port: 8080
+timeout: "3s"
+db:
+  host: "localhost"
+  password: "hunter2"
+`)
+	err = Unmarshal("decode", v, &decodeConfig{})
+	assert.EqualError(t, err, "config 'decode': key 'db.password' fails rule 'min=8', got ******")
+}
@@ -0,0 +1,221 @@
+package viper
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvVar is the environment variable selecting the active Env.
+	EnvVar = "APP_ENV"
+	// ConfigDirEnvVar is the environment variable selecting the config directory.
+	ConfigDirEnvVar = "APP_CONFIG_DIR"
+	// AdapterEnvVar is the environment variable selecting the Adapter driver, "local", "layered" or "fs".
+	AdapterEnvVar = "APP_CONFIG_ADAPTER"
+	// ConfigWatchEnvVar is the environment variable enabling the reload of the config files on change,
+	// such as "true" or "1", only the "local" adapter watches the files.
+	ConfigWatchEnvVar = "APP_CONFIG_WATCH"
+
+	DefaultEnv       = Testing
+	DefaultConfigDir = "configs"
+	DefaultAdapter   = "local"
+)
+
+var (
+	envs = []Env{Development, Testing, Production}
+	// the values set by flags, they take precedence over the environment variables
+	flagEnv, flagConfigDir, flagAdapter, flagWatch string
+	envMu                                          sync.RWMutex
+)
+
+// RegisterEnv adds a custom environment, such as "staging", to the known ones.
+func RegisterEnv(env Env) {
+	envMu.Lock()
+	defer envMu.Unlock()
+	if !slices.Contains(envs, env) {
+		envs = append(envs, env)
+	}
+}
+
+// Envs returns the known environments.
+func Envs() []Env {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	return slices.Clone(envs)
+}
+
+// ParseEnv returns the known environment named s, or an error listing the known environments.
+func ParseEnv(s string) (Env, error) {
+	known := Envs()
+	if slices.Contains(known, Env(s)) {
+		return Env(s), nil
+	}
+	names := make([]string, len(known))
+	for i, env := range known {
+		names[i] = string(env)
+	}
+	return "", fmt.Errorf("unknown env %q, known envs: %s", s, strings.Join(names, ", "))
+}
+
+// ActiveEnv returns the environment the application runs in. It is set by the flag registered with
+// BindFlags, then by the APP_ENV environment variable and defaults to Testing. An invalid value is returned
+// as is, the default adapter refuses to start with it.
+func ActiveEnv() Env {
+	env, _ := activeEnv()
+	return env
+}
+
+func activeEnv() (Env, error) {
+	envMu.RLock()
+	value := flagEnv
+	envMu.RUnlock()
+	if value == "" {
+		value = os.Getenv(EnvVar)
+	}
+	if value == "" {
+		return DefaultEnv, nil
+	}
+	env, err := ParseEnv(value)
+	if err != nil {
+		return Env(value), err
+	}
+	return env, nil
+}
+
+// ConfigDir returns the directory of the config files. It is set by the flag registered with BindFlags, then
+// by the APP_CONFIG_DIR environment variable and defaults to "configs".
+func ConfigDir() string {
+	envMu.RLock()
+	dir := flagConfigDir
+	envMu.RUnlock()
+	if dir == "" {
+		dir = os.Getenv(ConfigDirEnvVar)
+	}
+	if dir == "" {
+		dir = DefaultConfigDir
+	}
+	return dir
+}
+
+// ActiveAdapter returns the name of the Adapter driver reading the configs. It is set by the flag
+// registered with BindFlags, then by the APP_CONFIG_ADAPTER environment variable and defaults to "local".
+func ActiveAdapter() string {
+	envMu.RLock()
+	adapter := flagAdapter
+	envMu.RUnlock()
+	if adapter == "" {
+		adapter = os.Getenv(AdapterEnvVar)
+	}
+	if adapter == "" {
+		adapter = DefaultAdapter
+	}
+	return adapter
+}
+
+// ConfigWatch reports whether the config files are reloaded on change. It is set by the flag registered
+// with BindFlags, then by the APP_CONFIG_WATCH environment variable and defaults to false.
+func ConfigWatch() bool {
+	envMu.RLock()
+	value := flagWatch
+	envMu.RUnlock()
+	if value == "" {
+		value = os.Getenv(ConfigWatchEnvVar)
+	}
+	watch, _ := strconv.ParseBool(value)
+	return watch
+}
+
+// IsProduction reports whether the application runs in the Production environment.
+func IsProduction() bool {
+	return ActiveEnv() == Production
+}
+
+type envFlag struct{}
+
+func (envFlag) String() string {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	return flagEnv
+}
+
+func (envFlag) Set(s string) error {
+	env, err := ParseEnv(s)
+	if err != nil {
+		return err
+	}
+	envMu.Lock()
+	defer envMu.Unlock()
+	flagEnv = string(env)
+	return nil
+}
+
+type configDirFlag struct{}
+
+func (configDirFlag) String() string {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	return flagConfigDir
+}
+
+func (configDirFlag) Set(s string) error {
+	envMu.Lock()
+	defer envMu.Unlock()
+	flagConfigDir = s
+	return nil
+}
+
+type adapterFlag struct{}
+
+func (adapterFlag) String() string {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	return flagAdapter
+}
+
+func (adapterFlag) Set(s string) error {
+	envMu.Lock()
+	defer envMu.Unlock()
+	flagAdapter = s
+	return nil
+}
+
+type watchFlag struct{}
+
+func (watchFlag) String() string {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	return flagWatch
+}
+
+func (watchFlag) Set(s string) error {
+	watch, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	envMu.Lock()
+	defer envMu.Unlock()
+	flagWatch = strconv.FormatBool(watch)
+	return nil
+}
+
+func (watchFlag) IsBoolFlag() bool {
+	return true
+}
+
+// BindFlags registers the "env", "config-dir", "config-adapter" and "config-watch" flags, which take
+// precedence over the APP_ENV, APP_CONFIG_DIR, APP_CONFIG_ADAPTER and APP_CONFIG_WATCH environment
+// variables. It must be called before the flags are parsed.
+//
+//	viper.BindFlags(flag.CommandLine)
+//	flag.Parse()
+func BindFlags(fs *flag.FlagSet) {
+	fs.Var(envFlag{}, "env", fmt.Sprintf("config environment, overrides $%s (default %q)", EnvVar, DefaultEnv))
+	fs.Var(configDirFlag{}, "config-dir", fmt.Sprintf("config directory, overrides $%s (default %q)", ConfigDirEnvVar, DefaultConfigDir))
+	fs.Var(adapterFlag{}, "config-adapter", fmt.Sprintf("config adapter, overrides $%s (default %q)", AdapterEnvVar, DefaultAdapter))
+	fs.Var(watchFlag{}, "config-watch", fmt.Sprintf("reload the config files on change, overrides $%s", ConfigWatchEnvVar))
+}
@@ -0,0 +1,53 @@
+package viper
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func TestActiveEnv(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	assert.Equal(t, DefaultEnv, ActiveEnv())
+
+	t.Setenv(EnvVar, "production")
+	assert.Equal(t, Production, ActiveEnv())
+	assert.True(t, IsProduction())
+
+	t.Setenv(EnvVar, "staging")
+	_, err := activeEnv()
+	assert.EqualError(t, err, `unknown env "staging", known envs: development, testing, production`)
+
+	RegisterEnv("staging")
+	defer func() { envs = envs[:3] }()
+	env, err := activeEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, Env("staging"), env)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	BindFlags(fs)
+	defer func() { flagEnv, flagConfigDir, flagAdapter, flagWatch = "", "", "", "" }()
+	assert.Error(t, fs.Parse([]string{"-env", "prod"}))
+	assert.NoError(t, fs.Parse([]string{"-env", "development", "-config-dir", "/etc/app"}))
+	assert.Equal(t, Development, ActiveEnv())
+	assert.Equal(t, "/etc/app", ConfigDir())
+}
+
+func TestConfigWatch(t *testing.T) {
+	t.Setenv(ConfigWatchEnvVar, "")
+	assert.False(t, ConfigWatch())
+
+	t.Setenv(ConfigWatchEnvVar, "1")
+	assert.True(t, ConfigWatch())
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	BindFlags(fs)
+	defer func() { flagWatch = "" }()
+	assert.NoError(t, fs.Parse([]string{"-config-watch=false"}))
+	assert.False(t, ConfigWatch())
+	assert.NoError(t, fs.Parse([]string{"-config-watch"}))
+	assert.True(t, ConfigWatch())
+}
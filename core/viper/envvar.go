@@ -0,0 +1,82 @@
+package viper
+
+import (
+	"github.com/spf13/viper"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EnvPrefixVar is the environment variable setting the global prefix of the config environment variables,
+// see EnvVarName.
+const EnvPrefixVar = "APP_ENV_PREFIX"
+
+var (
+	envPrefix   string
+	envPrefixMu sync.RWMutex
+	envReplacer = strings.NewReplacer(".", "_", "-", "_")
+)
+
+// SetEnvPrefix sets the global prefix of the config environment variables, it takes precedence over the
+// APP_ENV_PREFIX environment variable. It must be called before the configs are read.
+func SetEnvPrefix(prefix string) {
+	envPrefixMu.Lock()
+	defer envPrefixMu.Unlock()
+	envPrefix = prefix
+}
+
+// EnvPrefix returns the global prefix of the config environment variables, empty by default.
+func EnvPrefix() string {
+	envPrefixMu.RLock()
+	prefix := envPrefix
+	envPrefixMu.RUnlock()
+	if prefix == "" {
+		prefix = os.Getenv(EnvPrefixVar)
+	}
+	return prefix
+}
+
+// EnvVarName returns the environment variable overriding the key of the named config. It is the upper
+// cased global prefix, config name and key joined by underscores, with the dashes and dots replaced by
+// underscores:
+//
+//	EnvVarName("gin-server", "http_port") // GIN_SERVER_HTTP_PORT
+//	EnvVarName("gorm", "pool.max")        // GORM_POOL_MAX, or MYAPP_GORM_POOL_MAX with the prefix "myapp"
+//
+// List values are written comma separated, such as "GET,POST".
+func EnvVarName(name, key string) string {
+	return strings.ToUpper(envReplacer.Replace(envVarPrefix(name) + "_" + key))
+}
+
+func envVarPrefix(name string) string {
+	if prefix := EnvPrefix(); prefix != "" {
+		return prefix + "_" + name
+	}
+	return name
+}
+
+// BindEnv makes v read the environment variables named by EnvVarName, adapters call it on the Viper
+// instances they create.
+func BindEnv(name string, v *viper.Viper) {
+	v.SetEnvPrefix(envVarPrefix(name))
+	v.SetEnvKeyReplacer(envReplacer)
+	v.AutomaticEnv()
+}
+
+// EnvVars returns the sorted environment variables honoured by the named config, one for each key of the
+// initial configuration, to be listed in deployment manifests.
+func EnvVars(name, initConfig string) ([]string, error) {
+	v := viper.New()
+	v.SetConfigType(detectContentType([]byte(initConfig)))
+	err := v.ReadConfig(strings.NewReader(initConfig))
+	if err != nil {
+		return nil, err
+	}
+	var vars []string
+	for _, key := range v.AllKeys() {
+		vars = append(vars, EnvVarName(name, key))
+	}
+	slices.Sort(vars)
+	return vars, nil
+}
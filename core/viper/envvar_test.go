@@ -0,0 +1,47 @@
+package viper_test
+
+import (
+	"github.com/aiechoic/admin/core/viper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEnvVarName(t *testing.T) {
+	assert.Equal(t, "GIN_SERVER_HTTP_PORT", viper.EnvVarName("gin-server", "http_port"))
+	assert.Equal(t, "AES_GCM_KEY", viper.EnvVarName("aes-gcm", "key"))
+	assert.Equal(t, "GORM_POOL_MAX", viper.EnvVarName("gorm", "pool.max"))
+
+	t.Setenv(viper.EnvPrefixVar, "myapp")
+	assert.Equal(t, "MYAPP_GIN_SERVER_HTTP_PORT", viper.EnvVarName("gin-server", "http_port"))
+}
+
+func TestLocalAdapter_EnvVars(t *testing.T) {
+	adapter := viper.NewLocalAdapter(t.TempDir(), viper.Testing)
+	initConfig := `
+http_port: 8080
+origins: ["*"]
+db:
+  host: "localhost"
+`
+	vars, err := viper.EnvVars("env-server", initConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ENV_SERVER_DB_HOST", "ENV_SERVER_HTTP_PORT", "ENV_SERVER_ORIGINS"}, vars)
+
+	t.Setenv("ENV_SERVER_HTTP_PORT", "9090")
+	t.Setenv("ENV_SERVER_DB_HOST", "db.example.com")
+	t.Setenv("ENV_SERVER_ORIGINS", "https://a.example.com,https://b.example.com")
+	v, err := adapter.NewViper("env-server", "yaml", initConfig)
+	assert.NoError(t, err)
+
+	var cfg struct {
+		HttpPort int      `mapstructure:"http_port"`
+		Origins  []string `mapstructure:"origins"`
+		DB       struct {
+			Host string `mapstructure:"host"`
+		} `mapstructure:"db"`
+	}
+	assert.NoError(t, viper.Unmarshal("env-server", v, &cfg))
+	assert.Equal(t, 9090, cfg.HttpPort)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.Origins)
+	assert.Equal(t, "db.example.com", cfg.DB.Host)
+}
@@ -0,0 +1,67 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+var (
+	embeddedFS   fs.FS
+	embeddedFSMu sync.RWMutex
+)
+
+// SetFS sets the file system read by the "fs" adapter, such as an embed.FS holding "<env>/<name>.<ext>"
+// files. The "fs" adapter reads the config directory if it is not set.
+//
+//	//go:embed configs
+//	var configs embed.FS
+//
+//	sub, _ := fs.Sub(configs, "configs")
+//	viper.SetFS(sub)
+func SetFS(fsys fs.FS) {
+	embeddedFSMu.Lock()
+	defer embeddedFSMu.Unlock()
+	embeddedFS = fsys
+}
+
+// FSAdapter is a read-only Adapter implementation reading the configuration files from a file system. The
+// initial configuration is used when a file is missing, nothing is ever written. Environment variables
+// override the settings the same way as with LocalAdapter.
+type FSAdapter struct {
+	fsys fs.FS
+	env  Env
+}
+
+func NewFSAdapter(fsys fs.FS, env Env) *FSAdapter {
+	return &FSAdapter{fsys: fsys, env: env}
+}
+
+// NewViper implements the Adapter interface.
+func (a *FSAdapter) NewViper(name, ext, initConfig string) (*viper.Viper, error) {
+	filename := path.Join(string(a.env), fmt.Sprintf("%s.%s", name, ext))
+	data, err := fs.ReadFile(a.fsys, filename)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return readInitConfig(name, ext, initConfig)
+	}
+	v := viper.New()
+	v.SetConfigType(ext)
+	err = v.ReadConfig(strings.NewReader(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading config file \"%s\": %w", filename, err)
+	}
+	BindEnv(name, v)
+	return v, nil
+}
+
+// ReadViper implements the Reader interface, the adapter never writes.
+func (a *FSAdapter) ReadViper(name, ext, initConfig string) (*viper.Viper, error) {
+	return a.NewViper(name, ext, initConfig)
+}
@@ -0,0 +1,52 @@
+package viper_test
+
+import (
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/viper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSAdapter_NewViper(t *testing.T) {
+	fsys := fstest.MapFS{
+		"production/fs-server.yaml": {Data: []byte("port: 80\nmode: \"release\"\n")},
+		"production/fs-broken.yaml": {Data: []byte("port: [80\n")},
+	}
+	adapter := viper.NewFSAdapter(fsys, viper.Production)
+
+	v, err := adapter.NewViper("fs-server", "yaml", `port: 8080`)
+	assert.NoError(t, err)
+	assert.Equal(t, 80, v.GetInt("port"))
+	assert.Equal(t, "release", v.GetString("mode"))
+
+	// missing files fall back to the initial configuration
+	v, err = adapter.NewViper("fs-missing", "yaml", `port: 8080`)
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, v.GetInt("port"))
+
+	_, err = adapter.NewViper("fs-broken", "yaml", `port: 8080`)
+	assert.Error(t, err)
+
+	t.Setenv("FS_SERVER_PORT", "9090")
+	v, err = adapter.NewViper("fs-server", "yaml", `port: 8080`)
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, v.GetInt("port"))
+}
+
+func TestActiveAdapter(t *testing.T) {
+	viper.SetFS(fstest.MapFS{
+		"testing/fs-selected.yaml": {Data: []byte(`key: "embedded"`)},
+	})
+	defer viper.SetFS(nil)
+	t.Setenv(viper.EnvVar, "")
+	t.Setenv(viper.AdapterEnvVar, "fs")
+
+	v, err := viper.GetViper("fs-selected", `key: "value"`, ioc.NewTestContainer(t))
+	assert.NoError(t, err)
+	assert.Equal(t, "embedded", v.GetString("key"))
+
+	t.Setenv(viper.AdapterEnvVar, "s3")
+	_, err = viper.GetViper("fs-selected", `key: "value"`, ioc.NewTestContainer(t))
+	assert.EqualError(t, err, `resolve viper.Adapter: config adapter: unknown driver "s3" for viper.Adapter, available drivers: fs, layered, local`)
+}
@@ -0,0 +1,183 @@
+package viper
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// BaseLayer is the directory of the LayeredAdapter holding the settings shared by all environments.
+	BaseLayer = "base"
+	// LocalLayer is the directory of the LayeredAdapter holding the settings of a single machine, it should
+	// be ignored by git.
+	LocalLayer = "local"
+	// EnvLayer is reported by LayeredAdapter.Source for the keys overridden by environment variables.
+	EnvLayer = "env"
+)
+
+// LayeredAdapter is a Adapter implementation that merges the configuration files of several directories, so
+// that the environments only contain the settings which differ. The files are read in the order
+// "<dir>/base/<name>.<ext>", "<dir>/<env>/<name>.<ext>" and "<dir>/local/<name>.<ext>", the later layers
+// take precedence. Maps are merged key by key and any other value, lists included, is replaced. Only the base
+// file is created from the initial configuration, the other layers are optional.
+type LayeredAdapter struct {
+	dir     string
+	env     Env
+	sources map[string]map[string]string
+	mu      sync.Mutex
+}
+
+// NewLayeredAdapter returns an adapter merging the layers of dir, the base directory is created with the
+// first file.
+func NewLayeredAdapter(dir string, env Env) *LayeredAdapter {
+	return &LayeredAdapter{
+		dir:     dir,
+		env:     env,
+		sources: map[string]map[string]string{},
+	}
+}
+
+// Layers returns the layers in the order they are merged.
+func (l *LayeredAdapter) Layers() []string {
+	return []string{BaseLayer, string(l.env), LocalLayer}
+}
+
+// NewViper implements the Adapter interface. Environment variables override the merged settings the same
+// way as with LocalAdapter.
+func (l *LayeredAdapter) NewViper(name, ext, initConfig string) (*viper.Viper, error) {
+	baseFile := l.DefaultFile(name, ext)
+	_, err := os.Stat(baseFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		err = os.MkdirAll(filepath.Dir(baseFile), 0755)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(baseFile, []byte(initConfig), 0644)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("created default config file '%s'", baseFile)
+	}
+	return l.ReadViper(name, ext, initConfig)
+}
+
+// ReadViper implements the Reader interface, the initial configuration is read in place of the base file
+// if it does not exist.
+func (l *LayeredAdapter) ReadViper(name, ext, initConfig string) (*viper.Viper, error) {
+	merged := map[string]any{}
+	sources := map[string]string{}
+	for _, layer := range l.Layers() {
+		filename := filepath.Join(l.dir, layer, fmt.Sprintf("%s.%s", name, ext))
+		var lv *viper.Viper
+		_, err := os.Stat(filename)
+		switch {
+		case err == nil:
+			lv = viper.New()
+			lv.SetConfigFile(filename)
+			err = lv.ReadInConfig()
+			if err != nil {
+				return nil, fmt.Errorf("reading config file \"%s\": %w", filename, err)
+			}
+		case !os.IsNotExist(err):
+			return nil, err
+		case layer == BaseLayer:
+			lv = viper.New()
+			lv.SetConfigType(ext)
+			err = lv.ReadConfig(strings.NewReader(initConfig))
+			if err != nil {
+				return nil, fmt.Errorf("reading initial config '%s': %w", name, err)
+			}
+		default:
+			continue
+		}
+		mergeLayer(merged, lv.AllSettings(), layer, "", sources)
+	}
+	v := viper.New()
+	err := v.MergeConfigMap(merged)
+	if err != nil {
+		return nil, err
+	}
+	BindEnv(name, v)
+	l.mu.Lock()
+	l.sources[name] = sources
+	l.mu.Unlock()
+	return v, nil
+}
+
+// DefaultFile implements the FileAdapter interface, it is the file of the base layer.
+func (l *LayeredAdapter) DefaultFile(name, ext string) string {
+	return filepath.Join(l.dir, BaseLayer, fmt.Sprintf("%s.%s", name, ext))
+}
+
+// Source returns the layer the value of the key comes from, it is EnvLayer if the key is overridden by an
+// environment variable. For a map it is the last layer setting one of its keys. An empty string is returned
+// if the key is not set or the config has not been read yet.
+func (l *LayeredAdapter) Source(name, key string) string {
+	key = strings.ToLower(key)
+	if _, ok := os.LookupEnv(EnvVarName(name, key)); ok {
+		return EnvLayer
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sources := l.sources[name]
+	if layer, ok := sources[key]; ok {
+		return layer
+	}
+	// a map, pick the layer with the highest precedence among its keys
+	var keys []string
+	for k := range sources {
+		if strings.HasPrefix(k, key+".") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	source, rank := "", -1
+	for _, k := range keys {
+		for i, layer := range l.Layers() {
+			if sources[k] == layer && i > rank {
+				source, rank = layer, i
+			}
+		}
+	}
+	return source
+}
+
+// mergeLayer merges src into dst, maps are merged recursively and the other values replaced. The layer of
+// each leaf key is recorded in sources.
+func mergeLayer(dst, src map[string]any, layer, prefix string, sources map[string]string) {
+	for k, v := range src {
+		key := prefix + k
+		sm, srcIsMap := v.(map[string]any)
+		dm, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeLayer(dm, sm, layer, key+".", sources)
+			continue
+		}
+		// the replaced value may be a map, forget the sources of its keys
+		for s := range sources {
+			if strings.HasPrefix(s, key+".") {
+				delete(sources, s)
+			}
+		}
+		if srcIsMap {
+			m := map[string]any{}
+			mergeLayer(m, sm, layer, key+".", sources)
+			dst[k] = m
+			if len(sm) == 0 {
+				sources[key] = layer
+			}
+			continue
+		}
+		dst[k] = v
+		sources[key] = layer
+	}
+}
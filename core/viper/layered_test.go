@@ -0,0 +1,63 @@
+package viper_test
+
+import (
+	"github.com/aiechoic/admin/core/viper"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLayeredAdapter_NewViper(t *testing.T) {
+	dir := t.TempDir()
+	adapter := viper.NewLayeredAdapter(dir, viper.Production)
+
+	initConfig := `
+port: 8080
+mode: "debug"
+origins: ["*"]
+db:
+  host: "localhost"
+  pool:
+    max: 10
+    idle: 2
+`
+	writeLayer := func(layer, content string) {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, layer), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, layer, "layered.yaml"), []byte(content), 0644))
+	}
+	writeLayer(string(viper.Production), `
+mode: "release"
+origins: ["https://example.com"]
+db:
+  host: "db.example.com"
+  pool:
+    max: 100
+`)
+	writeLayer(viper.LocalLayer, `
+db:
+  pool:
+    idle: 5
+`)
+
+	v, err := adapter.NewViper("layered", "yaml", initConfig)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, viper.BaseLayer, "layered.yaml"))
+
+	assert.Equal(t, 8080, v.GetInt("port"))
+	assert.Equal(t, "release", v.GetString("mode"))
+	assert.Equal(t, []string{"https://example.com"}, v.GetStringSlice("origins"))
+	assert.Equal(t, "db.example.com", v.GetString("db.host"))
+	assert.Equal(t, 100, v.GetInt("db.pool.max"))
+	assert.Equal(t, 5, v.GetInt("db.pool.idle"))
+
+	assert.Equal(t, viper.BaseLayer, adapter.Source("layered", "port"))
+	assert.Equal(t, "production", adapter.Source("layered", "origins"))
+	assert.Equal(t, "production", adapter.Source("layered", "db.pool.max"))
+	assert.Equal(t, viper.LocalLayer, adapter.Source("layered", "db.pool.idle"))
+	assert.Equal(t, viper.LocalLayer, adapter.Source("layered", "db"))
+	assert.Equal(t, "", adapter.Source("layered", "missing"))
+
+	t.Setenv("LAYERED_PORT", "9090")
+	assert.Equal(t, viper.EnvLayer, adapter.Source("layered", "port"))
+}
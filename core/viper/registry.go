@@ -0,0 +1,127 @@
+package viper
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the secret values printed by the config tools.
+const Redacted = "******"
+
+// Default is the initial configuration of a config name, see RegisterDefault.
+type Default struct {
+	Name string
+	// Config returns the initial configuration, it may depend on the ActiveEnv.
+	Config func() string
+}
+
+// Ext returns the file extension of the initial configuration.
+func (d Default) Ext() string {
+	return detectContentType([]byte(d.Config()))
+}
+
+var (
+	defaults   = map[string]Default{}
+	defaultsMu sync.RWMutex
+)
+
+// RegisterDefault registers the initial configuration of the default config name of a package, so that
+// the config tools can write and check the files without starting the application. It is usually called
+// from the init function of the package.
+func RegisterDefault(name, initConfig string) {
+	RegisterDefaultFunc(name, func() string { return initConfig })
+}
+
+// RegisterDefaultFunc is like RegisterDefault for the initial configurations depending on the ActiveEnv.
+func RegisterDefaultFunc(name string, initConfig func() string) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	defaults[name] = Default{Name: name, Config: initConfig}
+}
+
+// Defaults returns the registered initial configurations sorted by name.
+func Defaults() []Default {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	ds := make([]Default, 0, len(defaults))
+	for _, d := range defaults {
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].Name < ds[j].Name })
+	return ds
+}
+
+// LookupDefault returns the initial configuration registered for the name.
+func LookupDefault(name string) (Default, bool) {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	d, ok := defaults[name]
+	return d, ok
+}
+
+// DiffKeys compares the keys of a deployed config with its initial configuration, missing are the keys
+// added to the initial configuration since the file was written and unknown the keys it no longer has.
+func DiffKeys(initConfig string, v *viper.Viper) (missing, unknown []string, err error) {
+	iv := viper.New()
+	iv.SetConfigType(detectContentType([]byte(initConfig)))
+	err = iv.ReadConfig(strings.NewReader(initConfig))
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading initial config: %w", err)
+	}
+	initKeys, keys := iv.AllKeys(), v.AllKeys()
+	for _, key := range initKeys {
+		if !slices.Contains(keys, key) {
+			missing = append(missing, key)
+		}
+	}
+	for _, key := range keys {
+		if !slices.Contains(initKeys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(missing)
+	slices.Sort(unknown)
+	return missing, unknown, nil
+}
+
+// IsSecret reports whether the key holds a secret by its name, such as "password", "jwt.secret" or "dsn".
+func IsSecret(key string) bool {
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	key = strings.ToLower(key)
+	for _, s := range []string{"password", "secret", "token", "dsn"} {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return key == "key" || strings.HasSuffix(key, "_key")
+}
+
+// RedactedSettings returns the settings of v, environment variables included, with the secret values
+// replaced by Redacted.
+func RedactedSettings(v *viper.Viper) map[string]any {
+	settings := map[string]any{}
+	for _, key := range v.AllKeys() {
+		value := v.Get(key)
+		if IsSecret(key) && value != "" {
+			value = Redacted
+		}
+		m := settings
+		parts := strings.Split(key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := m[part].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				m[part] = sub
+			}
+			m = sub
+		}
+		m[parts[len(parts)-1]] = value
+	}
+	return settings
+}
@@ -0,0 +1,69 @@
+package viper
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDiffKeys(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(strings.NewReader(`
+port: 8080
+old_key: true
+db:
+  host: "localhost"
+`)))
+	missing, unknown, err := DiffKeys(`
+port: 8080
+timeout: "3s"
+db:
+  host: "localhost"
+  pool: 10
+`, v)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"db.pool", "timeout"}, missing)
+	assert.Equal(t, []string{"old_key"}, unknown)
+}
+
+func TestRedactedSettings(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(strings.NewReader(`
+host: "localhost"
+password: "p"
+username: ""
+db:
+  dsn: "host=localhost password=p"
+  secret_key: ""
+`)))
+	assert.Equal(t, map[string]any{
+		"host":     "localhost",
+		"password": Redacted,
+		"username": "",
+		"db": map[string]any{
+			"dsn":        Redacted,
+			"secret_key": "",
+		},
+	}, RedactedSettings(v))
+}
+
+func TestRegisterDefault(t *testing.T) {
+	RegisterDefault("registry-test", `key: "value"`)
+	defer func() {
+		defaultsMu.Lock()
+		delete(defaults, "registry-test")
+		defaultsMu.Unlock()
+	}()
+	d, ok := LookupDefault("registry-test")
+	assert.True(t, ok)
+	assert.Equal(t, "yaml", d.Ext())
+	assert.Equal(t, `key: "value"`, d.Config())
+	var names []string
+	for _, d := range Defaults() {
+		names = append(names, d.Name)
+	}
+	assert.Contains(t, names, "registry-test")
+}
@@ -0,0 +1,114 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// EncryptedPrefix marks a config value encrypted with the Decrypter, for example "enc:bm9uY2UuLi4=".
+const EncryptedPrefix = "enc:"
+
+// Decrypter decrypts the config values starting with EncryptedPrefix, the prefix is removed before the
+// value is passed to Decrypt.
+type Decrypter interface {
+	Decrypt(value string) (string, error)
+}
+
+var (
+	decrypter   Decrypter
+	decrypterMu sync.RWMutex
+)
+
+// SetDecrypter sets the Decrypter of the encrypted config values, the crypto package sets one using the
+// master key from the APP_MASTER_KEY environment variable when it is imported.
+func SetDecrypter(d Decrypter) {
+	decrypterMu.Lock()
+	defer decrypterMu.Unlock()
+	decrypter = d
+}
+
+var secretRef = regexp.MustCompile(`\$\{(env|file):([^}]+)}`)
+
+// resolveSecrets replaces the secret references of the string values, including those in lists, before the
+// config is unmarshalled:
+//
+//	password: "${env:DB_PASSWORD}"             # environment variable
+//	password: "${file:/run/secrets/db_password}" # file content, without the trailing new line
+//	password: "enc:bm9uY2UuLi4="               # value encrypted with the Decrypter
+//
+// References may be embedded in a longer string, such as a dsn.
+func resolveSecrets(name string, v *viper.Viper) error {
+	for _, key := range v.AllKeys() {
+		switch value := v.Get(key).(type) {
+		case string:
+			resolved, err := resolveSecret(value)
+			if err != nil {
+				return fmt.Errorf("config '%s' key '%s': %w", name, key, err)
+			}
+			if resolved != value {
+				v.Set(key, resolved)
+			}
+		case []any:
+			var changed bool
+			values := make([]any, len(value))
+			for i, item := range value {
+				values[i] = item
+				s, ok := item.(string)
+				if !ok {
+					continue
+				}
+				resolved, err := resolveSecret(s)
+				if err != nil {
+					return fmt.Errorf("config '%s' key '%s[%d]': %w", name, key, i, err)
+				}
+				if resolved != s {
+					values[i], changed = resolved, true
+				}
+			}
+			if changed {
+				v.Set(key, values)
+			}
+		}
+	}
+	return nil
+}
+
+func resolveSecret(value string) (string, error) {
+	if strings.HasPrefix(value, EncryptedPrefix) {
+		decrypterMu.RLock()
+		d := decrypter
+		decrypterMu.RUnlock()
+		if d == nil {
+			return "", errors.New("encrypted value but no decrypter is set, import the crypto package")
+		}
+		plain, err := d.Decrypt(strings.TrimPrefix(value, EncryptedPrefix))
+		if err != nil {
+			return "", fmt.Errorf("decrypting value: %w", err)
+		}
+		return plain, nil
+	}
+	var errs []error
+	resolved := secretRef.ReplaceAllStringFunc(value, func(ref string) string {
+		m := secretRef.FindStringSubmatch(ref)
+		switch m[1] {
+		case "env":
+			v, ok := os.LookupEnv(m[2])
+			if !ok {
+				errs = append(errs, fmt.Errorf("environment variable %q is not set", m[2]))
+			}
+			return v
+		default:
+			data, err := os.ReadFile(m[2])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("reading secret file: %w", err))
+			}
+			return strings.TrimRight(string(data), "\r\n")
+		}
+	})
+	return resolved, errors.Join(errs...)
+}
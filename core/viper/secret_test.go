@@ -0,0 +1,54 @@
+package viper
+
+import (
+	"errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type reverseDecrypter struct{}
+
+func (reverseDecrypter) Decrypt(value string) (string, error) {
+	if value == "" {
+		return "", errors.New("empty value")
+	}
+	r := []rune(value)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r), nil
+}
+
+func TestResolveSecrets(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("file_secret\n"), 0600))
+	t.Setenv("SECRET_TEST_USER", "admin")
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(strings.NewReader(`
+dsn: "user=${env:SECRET_TEST_USER} password=${file:`+secretFile+`}"
+key: "enc:terces"
+hosts: ["${env:SECRET_TEST_USER}.example.com", 8080]
+plain: "$name"
+`)))
+
+	err := resolveSecrets("secret", v)
+	assert.EqualError(t, err, "config 'secret' key 'key': encrypted value but no decrypter is set, import the crypto package")
+
+	SetDecrypter(reverseDecrypter{})
+	defer SetDecrypter(nil)
+	assert.NoError(t, resolveSecrets("secret", v))
+	assert.Equal(t, "user=admin password=file_secret", v.GetString("dsn"))
+	assert.Equal(t, "secret", v.GetString("key"))
+	assert.Equal(t, []any{"admin.example.com", 8080}, v.Get("hosts"))
+	assert.Equal(t, "$name", v.GetString("plain"))
+
+	v.Set("missing", "${env:SECRET_TEST_MISSING}")
+	err = resolveSecrets("secret", v)
+	assert.EqualError(t, err, `config 'secret' key 'missing': environment variable "SECRET_TEST_MISSING" is not set`)
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"github.com/BurntSushi/toml"
 	"github.com/aiechoic/admin/core/ioc"
+	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 const (
@@ -19,9 +21,50 @@ const (
 	Production  Env = "production"
 )
 
-// adapterProvider is a provider for Adapter, which is a factory for creating Viper instances.
+func init() {
+	ioc.RegisterConfigDriver("local", func(c *ioc.Container, cfg *AdapterConfig) (Adapter, error) {
+		adapter := NewLocalAdapter(cfg.Dir, cfg.Env)
+		if ConfigWatch() {
+			adapter.WithWatch()
+		}
+		return adapter, nil
+	})
+	ioc.RegisterConfigDriver("layered", func(c *ioc.Container, cfg *AdapterConfig) (Adapter, error) {
+		return NewLayeredAdapter(cfg.Dir, cfg.Env), nil
+	})
+	ioc.RegisterConfigDriver("fs", func(c *ioc.Container, cfg *AdapterConfig) (Adapter, error) {
+		embeddedFSMu.RLock()
+		fsys := embeddedFS
+		embeddedFSMu.RUnlock()
+		if fsys == nil {
+			fsys = os.DirFS(cfg.Dir)
+		}
+		return NewFSAdapter(fsys, cfg.Env), nil
+	})
+}
+
+// AdapterConfig is passed to the Adapter drivers, the adapters can not read their settings from a config
+// since they read the configs.
+type AdapterConfig struct {
+	// directory of the configs, see ConfigDir
+	Dir string
+	// environment of the configs, see ActiveEnv
+	Env Env
+}
+
+// adapterProvider is a provider for Adapter, which is a factory for creating Viper instances. By default
+// the files of the ActiveEnv are read from the ConfigDir by the Adapter driver named by ActiveAdapter,
+// more can be registered with ioc.RegisterConfigDriver taking an *AdapterConfig.
 var adapterProvider = ioc.NewProvider(func(c *ioc.Container) (Adapter, error) {
-	return NewLocalAdapter("configs", Testing), nil
+	env, err := activeEnv()
+	if err != nil {
+		return nil, err
+	}
+	adapter, err := ioc.NewConfigDriver[Adapter](ActiveAdapter(), c, &AdapterConfig{Dir: ConfigDir(), Env: env})
+	if err != nil {
+		return nil, fmt.Errorf("config adapter: %w", err)
+	}
+	return adapter, nil
 })
 
 // SetAdapter is a helper function to set the ViperAdapterProvider.
@@ -31,7 +74,21 @@ func SetAdapter(adapter Adapter) {
 	})
 }
 
-// GetViper creates a new Viper instance with the given name and initial configuration.
+// ReplaceAdapter returns a substitution which makes a container use the given adapter. Unlike SetAdapter
+// it only affects the containers it is applied to, which keeps parallel tests isolated.
+//
+//	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(t.TempDir(), viper.Testing)))
+func ReplaceAdapter(adapter Adapter) ioc.Substitution {
+	return ioc.Replace(adapterProvider, adapter)
+}
+
+// GetAdapter returns the adapter creating the Viper instances of c.
+func GetAdapter(c *ioc.Container) (Adapter, error) {
+	return adapterProvider.Get(c)
+}
+
+// GetViper creates a new Viper instance with the given name and initial configuration. The secret
+// references of the values are resolved, see resolveSecrets.
 func GetViper(name string, initConfig string, c *ioc.Container) (*viper.Viper, error) {
 	viperAdapter, err := adapterProvider.Get(c)
 	if err != nil {
@@ -41,7 +98,41 @@ func GetViper(name string, initConfig string, c *ioc.Container) (*viper.Viper, e
 	if contentType == "" {
 		return nil, fmt.Errorf("parameter 'initConfig' should be JSON, YAML, or TOML format")
 	}
-	return viperAdapter.NewViper(name, contentType, initConfig)
+	v, err := viperAdapter.NewViper(name, contentType, initConfig)
+	if err != nil {
+		return nil, err
+	}
+	err = resolveSecrets(name, v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ReadViper is like GetViper but reads the config without writing anything, the initial configuration is
+// used in place of the missing files. An error is returned if the adapter of c does not implement Reader.
+func ReadViper(name string, initConfig string, c *ioc.Container) (*viper.Viper, error) {
+	viperAdapter, err := adapterProvider.Get(c)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := viperAdapter.(Reader)
+	if !ok {
+		return nil, fmt.Errorf("config adapter %T can not read the configs without writing them", viperAdapter)
+	}
+	contentType := detectContentType([]byte(initConfig))
+	if contentType == "" {
+		return nil, fmt.Errorf("parameter 'initConfig' should be JSON, YAML, or TOML format")
+	}
+	v, err := reader.ReadViper(name, contentType, initConfig)
+	if err != nil {
+		return nil, err
+	}
+	err = resolveSecrets(name, v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
 type Env string
@@ -52,25 +143,44 @@ type Adapter interface {
 	NewViper(name, contentType string, initConfig string) (*viper.Viper, error)
 }
 
+// Reader is implemented by the adapters which can read a config the way NewViper does without writing
+// anything, it is used by the config tools.
+type Reader interface {
+	ReadViper(name, contentType string, initConfig string) (*viper.Viper, error)
+}
+
+// FileAdapter is implemented by the adapters storing the configs in files. DefaultFile returns the file
+// NewViper writes the initial configuration to when it does not exist.
+type FileAdapter interface {
+	DefaultFile(name, contentType string) string
+}
+
+// readInitConfig reads the initial configuration of a config which has no file.
+func readInitConfig(name, contentType, initConfig string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType(contentType)
+	err := v.ReadConfig(strings.NewReader(initConfig))
+	if err != nil {
+		return nil, fmt.Errorf("reading initial config '%s': %w", name, err)
+	}
+	BindEnv(name, v)
+	return v, nil
+}
+
 // LocalAdapter is a Adapter implementation that stores configuration files locally.
 type LocalAdapter struct {
-	dir string
-	env Env
+	Subscriptions
+	dir     string
+	env     Env
+	watch   bool
+	watcher *fsnotify.Watcher
+	files   map[string]*watchedFile
+	mu      sync.Mutex
 }
 
+// NewLocalAdapter returns an adapter reading the files of "<dir>/<env>", the directory is created with the
+// first file.
 func NewLocalAdapter(dir string, env Env) *LocalAdapter {
-	envDir := filepath.Join(dir, string(env))
-	_, err := os.Stat(envDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			err = os.MkdirAll(envDir, 0755)
-			if err != nil {
-				panic(err)
-			}
-		} else {
-			panic(err)
-		}
-	}
 	return &LocalAdapter{
 		dir: dir,
 		env: env,
@@ -78,15 +188,20 @@ func NewLocalAdapter(dir string, env Env) *LocalAdapter {
 }
 
 // NewViper implements the Adapter interface. If the configuration file does not exist, it creates a new
-// file with the initial configuration. It automatically read environment variables that match the prefix and
-// replace the separator with an underscore. For example, if the name is "app" and the separator is ".", the
-// environment variable "app.port" will be read as "app_port".
+// file with the initial configuration. It automatically reads the environment variables named by
+// EnvVarName, for example "APP_PORT" for the key "port" of the config "app" and "GIN_SERVER_HTTP_PORT" for
+// the key "http_port" of the config "gin-server". The file is watched for changes if the
+// adapter is created WithWatch.
 func (l *LocalAdapter) NewViper(name, ext, initConfig string) (*viper.Viper, error) {
 	initConfigData := []byte(initConfig)
-	filename := filepath.Join(l.dir, string(l.env), fmt.Sprintf("%s.%s", name, ext))
+	filename := l.DefaultFile(name, ext)
 	_, err := os.Stat(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
+			err = os.MkdirAll(filepath.Dir(filename), 0755)
+			if err != nil {
+				return nil, err
+			}
 			err = os.WriteFile(filename, initConfigData, 0644)
 			if err != nil {
 				return nil, err
@@ -96,16 +211,44 @@ func (l *LocalAdapter) NewViper(name, ext, initConfig string) (*viper.Viper, err
 			return nil, err
 		}
 	}
+	v, err := l.read(name, filename)
+	if err != nil {
+		return nil, err
+	}
+	err = l.watchFile(name, filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ReadViper implements the Reader interface, the initial configuration is read if the file does not exist.
+func (l *LocalAdapter) ReadViper(name, ext, initConfig string) (*viper.Viper, error) {
+	filename := l.DefaultFile(name, ext)
+	_, err := os.Stat(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		return readInitConfig(name, ext, initConfig)
+	}
+	return l.read(name, filename)
+}
+
+// DefaultFile implements the FileAdapter interface.
+func (l *LocalAdapter) DefaultFile(name, ext string) string {
+	return filepath.Join(l.dir, string(l.env), fmt.Sprintf("%s.%s", name, ext))
+}
+
+func (l *LocalAdapter) read(name, filename string) (*viper.Viper, error) {
 	v := viper.New()
 	// load environment variables
 	v.SetConfigFile(filename)
-	err = v.ReadInConfig()
+	err := v.ReadInConfig()
 	if err != nil {
 		return nil, fmt.Errorf("reading config file \"%s\": %w", filename, err)
 	}
-	v.SetEnvPrefix(name)
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	v.AutomaticEnv()
+	BindEnv(name, v)
 	return v, nil
 }
 
@@ -1,11 +1,14 @@
 package viper_test
 
 import (
+	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/viper"
+	spf13 "github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLocalViperAdapter_NewViper(t *testing.T) {
@@ -47,3 +50,148 @@ func TestLocalViperAdapter_NewViper(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "env_value", c.Port)
 }
+
+func TestReplaceAdapter(t *testing.T) {
+	dir := t.TempDir()
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(dir, viper.Testing)))
+
+	_, err := viper.GetViper("replaced", `key: "value"`, c)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "testing", "replaced.yaml"))
+}
+
+func TestLocalAdapter_WithWatch(t *testing.T) {
+	dir := t.TempDir()
+	adapter := viper.NewLocalAdapter(dir, viper.Testing).WithWatch()
+	defer adapter.Close()
+
+	_, err := adapter.NewViper("watched", "yaml", `level: 1`)
+	assert.NoError(t, err)
+
+	changes := make(chan int, 10)
+	cancel := adapter.Subscribe("watched", func(v *spf13.Viper) error {
+		changes <- v.GetInt("level")
+		return nil
+	})
+	defer cancel()
+
+	filename := filepath.Join(dir, "testing", "watched.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`level: 2`), 0644))
+	select {
+	case level := <-changes:
+		assert.Equal(t, 2, level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change not notified")
+	}
+
+	// invalid edits are not notified
+	assert.NoError(t, os.WriteFile(filename, []byte("level: [2"), 0644))
+	select {
+	case level := <-changes:
+		t.Fatalf("invalid config notified with level %d", level)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	cancel()
+	assert.NoError(t, os.WriteFile(filename, []byte(`level: 3`), 0644))
+	select {
+	case level := <-changes:
+		t.Fatalf("cancelled subscription notified with level %d", level)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	adapter1 := viper.NewLocalAdapter(t.TempDir(), viper.Testing)
+	adapter2 := viper.NewLocalAdapter(t.TempDir(), viper.Testing)
+	c1 := ioc.NewTestContainer(t, viper.ReplaceAdapter(adapter1))
+	c2 := ioc.NewTestContainer(t, viper.ReplaceAdapter(adapter2))
+
+	var levels1, levels2 []int
+	_, err := viper.OnChange("scoped", c1, func(v *spf13.Viper) error {
+		levels1 = append(levels1, v.GetInt("level"))
+		return nil
+	})
+	assert.NoError(t, err)
+	_, err = viper.OnChange("scoped", c2, func(v *spf13.Viper) error {
+		levels2 = append(levels2, v.GetInt("level"))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// the subscribers of a container are only notified of the changes of its adapter
+	v := spf13.New()
+	v.Set("level", 2)
+	assert.NoError(t, adapter1.Publish("scoped", v))
+	assert.Equal(t, []int{2}, levels1)
+	assert.Empty(t, levels2)
+
+	// closing the container cancels its subscriptions
+	assert.NoError(t, c1.Close())
+	assert.NoError(t, adapter1.Publish("scoped", v))
+	assert.Equal(t, []int{2}, levels1)
+
+	// so does closing a child container, the subscriptions of the parent are kept
+	child := c2.Child()
+	var childLevels []int
+	_, err = viper.OnChange("scoped", child, func(v *spf13.Viper) error {
+		childLevels = append(childLevels, v.GetInt("level"))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, adapter2.Publish("scoped", v))
+	assert.NoError(t, child.Close())
+	assert.NoError(t, adapter2.Publish("scoped", v))
+	assert.Equal(t, []int{2}, childLevels)
+	assert.Equal(t, []int{2, 2}, levels2)
+}
+
+type writeOnlyAdapter struct{}
+
+func (writeOnlyAdapter) NewViper(name, contentType string, initConfig string) (*spf13.Viper, error) {
+	return spf13.New(), nil
+}
+
+func TestReadViper(t *testing.T) {
+	dir := t.TempDir()
+	c := ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLocalAdapter(dir, viper.Testing)))
+	t.Setenv("READ_TOKEN", "s3cret")
+
+	// the initial configuration is read without writing the file, the secret references are resolved
+	v, err := viper.ReadViper("read", `token: "${env:READ_TOKEN}"`, c)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", v.GetString("token"))
+	assert.NoFileExists(t, filepath.Join(dir, "testing", "read.yaml"))
+
+	// the layers are merged the way the application reads them
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, viper.LocalLayer), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, viper.LocalLayer, "read.yaml"), []byte(`level: 2`), 0644))
+	c = ioc.NewTestContainer(t, viper.ReplaceAdapter(viper.NewLayeredAdapter(dir, viper.Testing)))
+	v, err = viper.ReadViper("read", `level: 1`+"\n"+`mode: "debug"`, c)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v.GetInt("level"))
+	assert.Equal(t, "debug", v.GetString("mode"))
+	assert.NoDirExists(t, filepath.Join(dir, viper.BaseLayer))
+
+	c = ioc.NewTestContainer(t, viper.ReplaceAdapter(writeOnlyAdapter{}))
+	_, err = viper.ReadViper("read", `level: 1`, c)
+	assert.ErrorContains(t, err, "can not read the configs without writing them")
+}
+
+func TestGetAdapter(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(viper.EnvVar, "production")
+	t.Setenv(viper.ConfigDirEnvVar, dir)
+	t.Setenv(viper.AdapterEnvVar, "layered")
+
+	adapter, err := viper.GetAdapter(ioc.NewTestContainer(t))
+	assert.NoError(t, err)
+	layered, ok := adapter.(*viper.LayeredAdapter)
+	assert.True(t, ok)
+	assert.Equal(t, []string{viper.BaseLayer, "production", viper.LocalLayer}, layered.Layers())
+	assert.Equal(t, filepath.Join(dir, viper.BaseLayer, "app.yaml"), layered.DefaultFile("app", "yaml"))
+
+	t.Setenv(viper.AdapterEnvVar, "mongo")
+	_, err = viper.GetAdapter(ioc.NewTestContainer(t))
+	assert.ErrorContains(t, err, `unknown driver "mongo"`)
+}
@@ -0,0 +1,312 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// reloadDelay coalesces the events of a single save, editors often truncate the file before writing it.
+const reloadDelay = 100 * time.Millisecond
+
+type subscription struct {
+	id    int
+	check func(v *viper.Viper) error
+	fn    func(v *viper.Viper) error
+}
+
+// Subscriptions holds the subscribers to the changes of the configs of an adapter. Adapters which reload
+// their configs embed it and call Publish, so only the subscribers using the adapter are notified. The
+// zero value is ready to use.
+type Subscriptions struct {
+	subs   map[string][]subscription
+	nextID int
+	mu     sync.Mutex
+}
+
+// Notifier is implemented by the adapters whose configs can change while the application runs, see
+// Subscriptions.
+type Notifier interface {
+	SubscribeCheck(name string, check, fn func(v *viper.Viper) error) (cancel func())
+}
+
+// Subscribe subscribes fn to the changes of the named config, the returned function cancels the
+// subscription.
+func (s *Subscriptions) Subscribe(name string, fn func(v *viper.Viper) error) (cancel func()) {
+	return s.SubscribeCheck(name, nil, fn)
+}
+
+// SubscribeCheck is like Subscribe, check validates a changed config without applying it, see Check. It
+// may be nil.
+func (s *Subscriptions) SubscribeCheck(name string, check, fn func(v *viper.Viper) error) (cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs == nil {
+		s.subs = map[string][]subscription{}
+	}
+	s.nextID++
+	id := s.nextID
+	s.subs[name] = append(s.subs[name], subscription{id: id, check: check, fn: fn})
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		subs := s.subs[name]
+		for i, sub := range subs {
+			if sub.id == id {
+				s.subs[name] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(s.subs[name]) == 0 {
+			delete(s.subs, name)
+		}
+	}
+}
+
+// Check resolves the secret references of a changed config and validates it with the checks of the
+// subscribers, it returns their joined errors. Adapters storing the configs call it before storing a
+// change, so an invalid config is never stored.
+func (s *Subscriptions) Check(name string, v *viper.Viper) error {
+	err := resolveSecrets(name, v)
+	if err != nil {
+		return err
+	}
+	return s.check(name, v)
+}
+
+func (s *Subscriptions) check(name string, v *viper.Viper) error {
+	var errs []error
+	for _, sub := range s.subscriptions(name) {
+		if sub.check == nil {
+			continue
+		}
+		if err := sub.check(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Publish checks a changed config and passes it to the subscribers if it is valid, it returns their
+// joined errors. Adapters call it when a config they created changes.
+func (s *Subscriptions) Publish(name string, v *viper.Viper) error {
+	err := resolveSecrets(name, v)
+	if err != nil {
+		return err
+	}
+	err = s.check(name, v)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sub := range s.subscriptions(name) {
+		if err := sub.fn(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Subscriptions) subscriptions(name string) []subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.subs[name])
+}
+
+// Clear cancels all the subscriptions, adapters call it when they are closed.
+func (s *Subscriptions) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = nil
+}
+
+// cancels keeps the cancel functions of the subscriptions made through a container, they are called when
+// the container is closed, so the instances of a closed container are no longer notified.
+type cancels struct {
+	fns []func()
+	mu  sync.Mutex
+}
+
+func (c *cancels) add(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fns = append(c.fns, fn)
+}
+
+func (c *cancels) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, fn := range c.fns {
+		fn()
+	}
+	c.fns = nil
+	return nil
+}
+
+// cancelsProvider is scoped, so the subscriptions made through a child container are cancelled with it.
+var cancelsProvider = ioc.NewScopedProvider(func(c *ioc.Container) (*cancels, error) {
+	return &cancels{}, nil
+})
+
+// OnChange subscribes fn to the changes of the named config read by the adapter of c, it is called with a
+// new Viper instance each time the adapter reloads the config. fn should apply the new settings only if
+// they are valid and return an error otherwise, the error is logged and the previous settings stay in
+// use. Nothing is subscribed if the adapter does not implement Notifier.
+//
+// The subscription is cancelled when c is closed, or earlier by the returned function.
+//
+//	cancel, err := viper.OnChange("gin-server", c, func(v *viper.Viper) error {
+//		var cfg Config
+//		if err := v.Unmarshal(&cfg); err != nil {
+//			return err
+//		}
+//		...
+//	})
+func OnChange(name string, c *ioc.Container, fn func(v *viper.Viper) error) (cancel func(), err error) {
+	return onChange(name, c, nil, fn)
+}
+
+func onChange(name string, c *ioc.Container, check, fn func(v *viper.Viper) error) (cancel func(), err error) {
+	adapter, err := adapterProvider.Get(c)
+	if err != nil {
+		return nil, err
+	}
+	notifier, ok := adapter.(Notifier)
+	if !ok {
+		return func() {}, nil
+	}
+	cs, err := cancelsProvider.Get(c)
+	if err != nil {
+		return nil, err
+	}
+	cancel = notifier.SubscribeCheck(name, check, fn)
+	cs.add(cancel)
+	return cancel, nil
+}
+
+// OnConfigChange is like OnChange but unmarshals and validates the new config into a T before calling fn.
+// The adapters storing the configs reject the changes which can not be unmarshalled.
+func OnConfigChange[T any](name string, c *ioc.Container, fn func(cfg *T) error) (cancel func(), err error) {
+	check := func(v *viper.Viper) error {
+		var cfg T
+		return Unmarshal(name, v, &cfg)
+	}
+	return onChange(name, c, check, func(v *viper.Viper) error {
+		var cfg T
+		err := Unmarshal(name, v, &cfg)
+		if err != nil {
+			return err
+		}
+		return fn(&cfg)
+	})
+}
+
+type watchedFile struct {
+	name  string
+	timer *time.Timer
+}
+
+// WithWatch makes the adapter watch the config files it has read and reload them on change, the
+// subscribers registered with OnChange receive the new config. It is disabled by default, the "local"
+// driver enables it when ConfigWatch is true.
+func (l *LocalAdapter) WithWatch() *LocalAdapter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.watch = true
+	return l
+}
+
+// Close stops watching the config files and cancels the subscriptions.
+func (l *LocalAdapter) Close() error {
+	l.Clear()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.watcher == nil {
+		return nil
+	}
+	for _, f := range l.files {
+		if f.timer != nil {
+			f.timer.Stop()
+		}
+	}
+	err := l.watcher.Close()
+	l.watcher = nil
+	return err
+}
+
+func (l *LocalAdapter) watchFile(name, filename string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.watch {
+		return nil
+	}
+	if l.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("watching config files: %w", err)
+		}
+		// watch the directory rather than the files, editors often replace the file on save
+		err = watcher.Add(filepath.Dir(filename))
+		if err != nil {
+			watcher.Close()
+			return fmt.Errorf("watching config files: %w", err)
+		}
+		l.watcher = watcher
+		l.files = map[string]*watchedFile{}
+		go l.watchLoop(watcher)
+	}
+	if _, ok := l.files[filename]; !ok {
+		l.files[filename] = &watchedFile{name: name}
+	}
+	return nil
+}
+
+func (l *LocalAdapter) watchLoop(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			l.mu.Lock()
+			f, ok := l.files[filepath.Clean(event.Name)]
+			if ok {
+				if f.timer != nil {
+					f.timer.Stop()
+				}
+				filename := filepath.Clean(event.Name)
+				f.timer = time.AfterFunc(reloadDelay, func() { l.reload(f.name, filename) })
+			}
+			l.mu.Unlock()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logrus.Errorf("watching config files: %v", err)
+		}
+	}
+}
+
+func (l *LocalAdapter) reload(name, filename string) {
+	v, err := l.read(name, filename)
+	if err != nil {
+		logrus.Errorf("config '%s' not reloaded, keeping the previous config: %v", name, err)
+		return
+	}
+	err = l.Publish(name, v)
+	if err != nil {
+		logrus.Errorf("config '%s' not reloaded, keeping the previous config: %v", name, err)
+		return
+	}
+	logrus.Infof("reloaded config file '%s'", filename)
+}
@@ -2,13 +2,25 @@ package main
 
 import (
 	"context"
+	"flag"
 	"github.com/aiechoic/admin/core/gins"
+	"github.com/aiechoic/admin/core/health"
+	"github.com/aiechoic/admin/core/health/healthsvc"
 	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/metrics"
+	// registers the redis rate limiter of the login route
+	_ "github.com/aiechoic/admin/core/redis"
+	"github.com/aiechoic/admin/core/viper"
 	"github.com/aiechoic/admin/examples/auth/src"
+	"github.com/aiechoic/admin/src/debug"
 	"github.com/aiechoic/admin/src/doc"
+	"log"
 )
 
 func main() {
+	viper.BindFlags(flag.CommandLine)
+	flag.Parse()
+
 	c := ioc.NewContainer()
 
 	server, err := gins.GetDefaultAPIServer(c)
@@ -16,10 +28,30 @@ func main() {
 		panic(err)
 	}
 
+	h, err := health.GetDefaultHealth(c)
+	if err != nil {
+		panic(err)
+	}
+
+	m, err := metrics.GetDefaultMetrics(c)
+	if err != nil {
+		panic(err)
+	}
+
 	server.Register(
 		src.NewService(c),
-		doc.NewService(server.API),
+		healthsvc.NewService(h, server.Engin),
+		metrics.NewService(m),
 	)
+	if !viper.IsProduction() {
+		server.Register(
+			doc.NewService(server.API),
+			debug.NewService(c, nil),
+		)
+	}
 
-	server.Run(context.Background())
+	err = server.Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,6 +1,13 @@
 package src
 
+import "strconv"
+
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
 }
+
+// Identity implements jwt.Identifier, the user id is logged in the access log.
+func (u *User) Identity() string {
+	return strconv.Itoa(u.ID)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/aiechoic/admin/core/gins"
 	"github.com/aiechoic/admin/core/ioc"
 	"github.com/aiechoic/admin/core/jwt"
+	"time"
 )
 
 func NewService(c *ioc.Container) *gins.Service {
@@ -17,9 +18,10 @@ func NewService(c *ioc.Container) *gins.Service {
 		Path: "/user",
 		Routes: []gins.Route{
 			{
-				Method:  "POST",
-				Path:    "login",
-				Handler: hs.Login(),
+				Method:    "POST",
+				Path:      "login",
+				RateLimit: &gins.RateLimit{Limit: 5, Window: time.Minute},
+				Handler:   hs.Login(),
 			},
 			{
 				Method:   "GET",
@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"flag"
 	"github.com/aiechoic/admin/core/gins"
 	"github.com/aiechoic/admin/core/ioc"
+	"github.com/aiechoic/admin/core/viper"
 	"github.com/aiechoic/admin/examples/upload/src"
 	"github.com/aiechoic/admin/src/doc"
+	"log"
 )
 
 func main() {
+	viper.BindFlags(flag.CommandLine)
+	flag.Parse()
+
 	c := ioc.NewContainer()
 
 	server, err := gins.GetDefaultAPIServer(c)
@@ -16,10 +22,13 @@ func main() {
 		panic(err)
 	}
 
-	server.Register(
-		src.NewService(c),
-		doc.NewService(server.API),
-	)
+	server.Register(src.NewService(c))
+	if !viper.IsProduction() {
+		server.Register(doc.NewService(server.API))
+	}
 
-	server.Run(context.Background())
+	err = server.Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -12,12 +12,14 @@ var codeText = map[Code]string{
 	BadRequest:          "Bad Request",
 	Unauthorized:        "Unauthorized",
 	InternalServerError: "Internal Server Error",
+	TooManyRequests:     "Too Many Requests",
 }
 
 const (
 	BadRequest Code = iota + 4000
 	Unauthorized
 	InternalServerError
+	TooManyRequests
 )
 
 func (c Code) String() string {
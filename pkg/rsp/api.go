@@ -19,9 +19,10 @@ func SendError(c *gin.Context, code errs.Code, err error) {
 		err = code
 	}
 	c.JSON(200, Response{
-		Success: false,
-		Error:   err.Error(),
-		Code:    code,
-		Data:    nil,
+		Success:   false,
+		Error:     err.Error(),
+		Code:      code,
+		Data:      nil,
+		RequestID: c.GetString(RequestIDKey),
 	})
 }
@@ -2,9 +2,14 @@ package rsp
 
 import "github.com/aiechoic/admin/pkg/errs"
 
+// RequestIDKey is the gin context key of the request id, set by the request id middleware of core/gin.
+const RequestIDKey = "request_id"
+
 type Response struct {
 	Success bool      `json:"success"`
 	Error   string    `json:"error"`
 	Code    errs.Code `json:"code"`
 	Data    any       `json:"data"`
+	// id of the failed request, for correlating the reports with the access log
+	RequestID string `json:"request_id,omitempty"`
 }
@@ -0,0 +1,49 @@
+package debug
+
+import (
+	"github.com/aiechoic/admin/core/gins"
+	"github.com/aiechoic/admin/core/ioc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// NewService creates a service exposing the dependency graph of the container c, it is recommended to
+// protect the service with a security in production.
+func NewService(c *ioc.Container, security gins.Security) *gins.Service {
+	return &gins.Service{
+		Tag:         "Debug",
+		Description: "Runtime diagnostics of the application",
+		Path:        "/debug",
+		Security:    security,
+		Routes: []gins.Route{
+			{
+				Method:      "GET",
+				Path:        "ioc/graph.json",
+				Summary:     "Dependency graph of the container",
+				Description: "Providers resolved so far and the providers they depend on",
+				Handler: gins.Handler{
+					Response: gins.Response{
+						Json: ioc.Graph{},
+					},
+					Handle: func(ctx *gin.Context) {
+						ctx.JSON(http.StatusOK, c.Graph())
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "ioc/graph.dot",
+				Summary:     "Dependency graph of the container in the Graphviz DOT language",
+				Description: "Render it with: curl .../debug/ioc/graph.dot | dot -Tsvg > graph.svg",
+				Handler: gins.Handler{
+					Response: gins.Response{
+						Contents: gins.ContentsTextPlain,
+					},
+					Handle: func(ctx *gin.Context) {
+						ctx.String(http.StatusOK, c.Graph().DOT())
+					},
+				},
+			},
+		},
+	}
+}